@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/bjosv/kubectl-rediscluster/pkg/cmd"
@@ -29,8 +30,26 @@ func main() {
 	root.AddCommand(cmd.NewVersionCmd(streams.Out))
 	root.AddCommand(cmd.NewSlotsCmd(streams))
 	root.AddCommand(cmd.NewNodesCmd(streams))
+	root.AddCommand(cmd.NewSlotmapCmd(streams))
+	root.AddCommand(cmd.NewTopologyCmd(streams))
+	root.AddCommand(cmd.NewReportCmd(streams))
+	root.AddCommand(cmd.NewHealthCmd(streams))
+	root.AddCommand(cmd.NewBalanceCmd(streams))
+	root.AddCommand(cmd.NewReplicationCmd(streams))
+	root.AddCommand(cmd.NewCheckCmd(streams))
+	root.AddCommand(cmd.NewMemoryCmd(streams))
+	root.AddCommand(cmd.NewBigkeysCmd(streams))
+	root.AddCommand(cmd.NewSlowlogCmd(streams))
+	root.AddCommand(cmd.NewClientsCmd(streams))
+	root.AddCommand(cmd.NewLatencyCmd(streams))
+	root.AddCommand(cmd.NewConfigCmd(streams))
+	root.AddCommand(cmd.NewPersistenceCmd(streams))
 
 	if err := root.Execute(); err != nil {
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
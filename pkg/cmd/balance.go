@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Output document of the balance command, with the distribution of slots and keys over the masters
+type balanceList struct {
+	outputList
+	SlotsBalance balanceSummary `json:"slotsBalance"`
+	KeysBalance  balanceSummary `json:"keysBalance"`
+}
+
+type balanceCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+
+	*clusterState
+}
+
+// The share of slots and keys of a master. The pod is given by its address when unknown,
+// and the number of keys is -1 when unknown, like when the master is unreachable.
+type masterBalance struct {
+	Pod            string  `json:"pod"`
+	Addr           string  `json:"addr"`
+	Host           string  `json:"host"`
+	Slots          int     `json:"slots"`
+	SlotRanges     int     `json:"slotRanges"`
+	SlotsDeviation float64 `json:"slotsDeviation"`
+	Keys           int64   `json:"keys"`
+	KeysDeviation  float64 `json:"keysDeviation"`
+}
+
+// Distribution of a value over all masters
+type balanceSummary struct {
+	Ideal             float64 `json:"ideal"`
+	StandardDeviation float64 `json:"standardDeviation"`
+	MostLoaded        string  `json:"mostLoaded,omitempty"`
+	LeastLoaded       string  `json:"leastLoaded,omitempty"`
+}
+
+// NewBalanceCmd initialize and creates a Cobra command
+func NewBalanceCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &balanceCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "balance [service-name] [flags]",
+		Short: "Show the balance of slots and keys between the masters of a Redis Cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *balanceCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *balanceCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *balanceCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return c.printOutput()
+	}
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *balanceCmd) printOutput() error {
+	masters, idealSlots, idealKeys := c.masterBalances()
+	list := balanceList{outputList: *c.newOutputList("BalanceList", masters)}
+	list.SlotsBalance, list.KeysBalance = balanceSummaries(masters, idealSlots, idealKeys)
+	return printOutput(list, c.output, c.streams.Out)
+}
+
+func (c *balanceCmd) outputResult(out io.Writer) {
+	masters, idealSlots, idealKeys := c.masterBalances()
+	if len(masters) == 0 {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to find any masters to show..")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\t\t\tSLOT\tSLOTS\t\tKEYS")
+	fmt.Fprintln(w, "PODNAME\tHOST\tSLOTS\tRANGES\tDEVIATION\tKEYS\tDEVIATION")
+	for _, m := range masters {
+		keys, keysDeviation := "?", "?"
+		if m.Keys >= 0 {
+			keys, keysDeviation = fmt.Sprintf("%d", m.Keys), formatDeviation(m.KeysDeviation)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n", m.Pod, m.Host, m.Slots, m.SlotRanges,
+			formatDeviation(m.SlotsDeviation), keys, keysDeviation)
+	}
+
+	slots, keys := balanceSummaries(masters, idealSlots, idealKeys)
+	fmt.Fprintf(w, "\n\tIDEAL\tSTDDEV\tMOST LOADED\tLEAST LOADED\n")
+	fmt.Fprintf(w, "Slots:\t%.1f\t%.1f\t%s\t%s\n", slots.Ideal, slots.StandardDeviation, slots.MostLoaded, slots.LeastLoaded)
+	fmt.Fprintf(w, "Keys:\t%.1f\t%.1f\t%s\t%s\n", keys.Ideal, keys.StandardDeviation, keys.MostLoaded, keys.LeastLoaded)
+
+	c.outputErrors(w)
+}
+
+// masterBalances returns the share of slots and keys per master, ordered by the number of slots.
+// The masters are the addresses serving slots in the shown CLUSTER SLOTS view, also when
+// the pod is unknown, and pods that reports themselves as masters without slots, like a
+// newly added master. Masters with an unknown number of keys are left out of the ideal number of keys.
+// The ideal number of slots and keys per master are also returned.
+func (s *clusterState) masterBalances() ([]masterBalance, float64, float64) {
+	slots := s.redisSlots[s.slotsPodName()]
+
+	masters := map[string]masterBalance{}
+	for _, slot := range slots {
+		if len(slot.Nodes) > 0 {
+			addr := slot.Nodes[0].Addr
+			masters[addr] = masterBalance{Pod: s.addrName(addr), Addr: addr, Host: s.k8sInfo.GetPodInfo(addr).Host}
+		}
+	}
+	for _, p := range s.podList() {
+		if s.podRole(p.Name) == roleMaster {
+			addr := fmt.Sprintf("%s:%d", p.IP, redisutils.RedisPort)
+			masters[addr] = masterBalance{Pod: p.Name, Addr: addr, Host: p.Host}
+		}
+	}
+
+	result := []masterBalance{}
+	totalKeys := int64(0)
+	knownKeys := 0
+	for addr, m := range masters {
+		m.Slots, m.SlotRanges = addrSlotsCount(addr, slots)
+		m.Keys = s.podInfoValue(m.Pod, "keys")
+		if m.Keys >= 0 {
+			totalKeys += m.Keys
+			knownKeys++
+		}
+		result = append(result, m)
+	}
+	if len(result) == 0 {
+		return result, 0, 0
+	}
+
+	idealSlots := float64(redisutils.SlotCount) / float64(len(result))
+	idealKeys := 0.0
+	if knownKeys > 0 {
+		idealKeys = float64(totalKeys) / float64(knownKeys)
+	}
+	for i := range result {
+		result[i].SlotsDeviation = deviation(float64(result[i].Slots), idealSlots)
+		if result[i].Keys >= 0 {
+			result[i].KeysDeviation = deviation(float64(result[i].Keys), idealKeys)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Slots != result[j].Slots {
+			return result[i].Slots > result[j].Slots
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, idealSlots, idealKeys
+}
+
+// balanceSummaries returns the distribution of slots and keys over the masters,
+// given the ideal number of slots and keys per master
+func balanceSummaries(masters []masterBalance, idealSlots float64, idealKeys float64) (balanceSummary, balanceSummary) {
+	slots := []float64{}
+	keys := []float64{}
+	keyMasters := []masterBalance{}
+	for _, m := range masters {
+		slots = append(slots, float64(m.Slots))
+		if m.Keys >= 0 {
+			keys = append(keys, float64(m.Keys))
+			keyMasters = append(keyMasters, m)
+		}
+	}
+	if len(masters) == 0 {
+		return balanceSummary{}, balanceSummary{}
+	}
+	keysSummary := balanceSummary{}
+	if len(keyMasters) > 0 {
+		keysSummary = summarizeBalance(keyMasters, keys, idealKeys)
+	}
+	return summarizeBalance(masters, slots, idealSlots), keysSummary
+}
+
+// summarizeBalance calculates the standard deviation from the ideal value of
+// given values, one per master, and finds the most and least loaded master
+func summarizeBalance(masters []masterBalance, values []float64, ideal float64) balanceSummary {
+	summary := balanceSummary{Ideal: ideal}
+
+	most, least := 0, 0
+	variance := 0.0
+	for i, v := range values {
+		if v > values[most] {
+			most = i
+		}
+		if v < values[least] {
+			least = i
+		}
+		variance += (v - ideal) * (v - ideal)
+	}
+	summary.StandardDeviation = math.Sqrt(variance / float64(len(values)))
+
+	if values[most] != values[least] {
+		summary.MostLoaded = masters[most].Pod
+		summary.LeastLoaded = masters[least].Pod
+	}
+	return summary
+}
+
+// deviation returns how much a value differs from the ideal value, in percent
+func deviation(value float64, ideal float64) float64 {
+	if ideal == 0 {
+		return 0
+	}
+	return math.Round((value-ideal)/ideal*1000) / 10
+}
+
+func formatDeviation(percent float64) string {
+	return fmt.Sprintf("%+.1f%%", percent)
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Defaults limiting the load of the key scan on each master
+const (
+	defaultScanCount = 100
+	defaultScanSleep = 100 * time.Millisecond
+)
+
+// Output document of the bigkeys command, with the number of scanned keys and their memory usage per type
+type bigKeyList struct {
+	outputList
+	KeyTypes []keyTypeSummary `json:"keyTypes,omitempty"`
+}
+
+type bigkeysCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	top         int
+	count       int64
+	sleep       time.Duration
+
+	*clusterState
+}
+
+// A big key and where it is stored
+type bigKey struct {
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Bytes int64  `json:"bytes"`
+	Slot  int    `json:"slot"`
+	Pod   string `json:"pod"`
+	Host  string `json:"host"`
+}
+
+// Number of scanned keys and their total memory usage, per type
+type keyTypeSummary struct {
+	Type  string `json:"type"`
+	Keys  int64  `json:"keys"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Result from scanning a master
+type scanResult struct {
+	keys    map[string][]bigKey
+	summary map[string]*keyTypeSummary
+}
+
+// NewBigkeysCmd initialize and creates a Cobra command
+func NewBigkeysCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &bigkeysCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "bigkeys [service-name] [flags]",
+		Short: "Scan all masters of a Redis Cluster for the biggest keys per type",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().IntVar(&c.top, "top", 10, "Number of keys to show per type")
+	cmd.Flags().Int64Var(&c.count, "count", defaultScanCount, "Number of keys to fetch per SCAN batch")
+	cmd.Flags().DurationVar(&c.sleep, "sleep", defaultScanSleep, "Time to sleep between SCAN batches on each master")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *bigkeysCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *bigkeysCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.top <= 0 {
+		return fmt.Errorf("top must be a positive number, got %d", c.top)
+	}
+	if c.count <= 0 {
+		return fmt.Errorf("count must be a positive number, got %d", c.count)
+	}
+	if c.sleep < 0 {
+		return fmt.Errorf("sleep can not be negative, got %s", c.sleep)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *bigkeysCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+	// Reuse the connections of the collect when running the commands on the pods
+	query.keepConnections = true
+	defer query.close()
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	keys, summary := c.scanMasters(query)
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		list := bigKeyList{outputList: *c.newOutputList("BigKeyList", keys), KeyTypes: summary}
+		return printOutput(list, c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out, keys, summary)
+
+	return nil
+}
+
+func (c *bigkeysCmd) outputResult(out io.Writer, keys []bigKey, summary []keyTypeSummary) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "TYPE\tKEY\tSIZE\tSLOT\tPODNAME\tHOST")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%q\t%s\t%d\t%s\t%s\n", k.Type, k.Key, formatBytes(k.Bytes), k.Slot, k.Pod, k.Host)
+	}
+
+	if len(summary) > 0 {
+		fmt.Fprintf(w, "\nScanned keys:\n")
+		fmt.Fprintln(w, "TYPE\tKEYS\tSIZE\tAVERAGE")
+		for _, s := range summary {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.Type, s.Keys, formatBytes(s.Bytes), formatBytes(s.Bytes/s.Keys))
+		}
+	}
+
+	c.outputErrors(w)
+}
+
+// scanMasters scans all masters in parallel, each one rate limited by the SCAN count and
+// the sleep between batches. Returns the top keys per type ordered by type and size,
+// and a summary per type.
+func (c *bigkeysCmd) scanMasters(query *clusterQuery) ([]bigKey, []keyTypeSummary) {
+	masters := []k8s.PodInfo{}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; ok && c.podRole(p.Name) == roleMaster {
+			masters = append(masters, p)
+		}
+	}
+	results := query.runOnPods(masters, func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error) {
+		return c.scanPod(pod, conn)
+	})
+
+	keys := map[string][]bigKey{}
+	summary := map[string]*keyTypeSummary{}
+	for _, r := range results {
+		if r.err != nil {
+			c.errors[r.pod.Name] = append(c.errors[r.pod.Name], fmt.Sprintf("scan failed: %v", r.err))
+		}
+		if r.value == nil {
+			continue
+		}
+		result := r.value.(scanResult)
+		for keyType, top := range result.keys {
+			keys[keyType] = topKeys(append(keys[keyType], top...), c.top)
+		}
+		for keyType, s := range result.summary {
+			if _, found := summary[keyType]; !found {
+				summary[keyType] = &keyTypeSummary{Type: keyType}
+			}
+			summary[keyType].Keys += s.Keys
+			summary[keyType].Bytes += s.Bytes
+		}
+	}
+
+	types := []string{}
+	for keyType := range summary {
+		types = append(types, keyType)
+	}
+	sort.Strings(types)
+
+	resultKeys := []bigKey{}
+	resultSummary := []keyTypeSummary{}
+	for _, keyType := range types {
+		resultKeys = append(resultKeys, keys[keyType]...)
+		resultSummary = append(resultSummary, *summary[keyType])
+	}
+	return resultKeys, resultSummary
+}
+
+// scanPod scans the keys of a pod, keeping the top keys per type.
+// The keys scanned before an error are kept.
+func (c *bigkeysCmd) scanPod(pod k8s.PodInfo, conn *redisutils.Connection) (scanResult, error) {
+	result := scanResult{
+		keys:    map[string][]bigKey{},
+		summary: map[string]*keyTypeSummary{},
+	}
+	err := conn.ScanKeySizes(c.count, c.sleep, func(sizes []redisutils.KeySize) {
+		for _, size := range sizes {
+			if _, found := result.summary[size.Type]; !found {
+				result.summary[size.Type] = &keyTypeSummary{Type: size.Type}
+			}
+			result.summary[size.Type].Keys++
+			result.summary[size.Type].Bytes += size.Bytes
+
+			top := result.keys[size.Type]
+			if len(top) == c.top && top[len(top)-1].Bytes >= size.Bytes {
+				continue
+			}
+			result.keys[size.Type] = topKeys(append(top, bigKey{
+				Type:  size.Type,
+				Key:   size.Key,
+				Bytes: size.Bytes,
+				Slot:  redisutils.KeySlot(size.Key),
+				Pod:   pod.Name,
+				Host:  pod.Host,
+			}), c.top)
+		}
+	})
+	return result, err
+}
+
+// topKeys returns the n biggest keys, ordered by size
+func topKeys(keys []bigKey, n int) []bigKey {
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Bytes > keys[j].Bytes
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
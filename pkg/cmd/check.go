@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Status of a check, in order of severity
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// Exit codes of the check command, by severity
+var checkExitCodes = map[string]int{checkPass: 0, checkWarn: 1, checkFail: 2}
+
+// Exit code of the check command when the cluster could not be checked, like on
+// invalid arguments or flags or a missing service, keeping 1 and 2 for WARN and FAIL
+const checkErrorExitCode = 3
+
+// ExitError is returned by commands that should exit with a specific code.
+// The error message is empty when the command already has printed its result.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+type checkCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	maxLag      int64
+
+	*clusterState
+}
+
+// Result of one check
+type checkResult struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Details []string `json:"details,omitempty"`
+}
+
+// NewCheckCmd initialize and creates a Cobra command
+func NewCheckCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &checkCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "check [service-name] [flags]",
+		Short: "Check the health of a Redis Cluster, exits with 0 on PASS, 1 on WARN, 2 on FAIL and 3 when not able to check",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return &ExitError{Code: checkErrorExitCode, Err: err}
+			}
+			if err := c.Validate(); err != nil {
+				return &ExitError{Code: checkErrorExitCode, Err: err}
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			status, err := c.Run()
+			if err != nil {
+				return &ExitError{Code: checkErrorExitCode, Err: err}
+			}
+			if status != checkPass {
+				cmd.SilenceErrors = true
+				return &ExitError{Code: checkExitCodes[status]}
+			}
+			return nil
+		},
+	}
+
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &ExitError{Code: checkErrorExitCode, Err: err}
+	})
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().Int64Var(&c.maxLag, "max-lag", defaultMaxLag, "Replication offset lag, in bytes, above which a replica is considered lagging")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *checkCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *checkCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.maxLag < 0 {
+		return fmt.Errorf("max-lag can not be negative, got %d", c.maxLag)
+	}
+
+	return nil
+}
+
+// Run the command, returns the most severe status of all checks
+func (c *checkCmd) Run() (string, error) {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return "", err
+	}
+	query.withZones = true
+
+	state, err := query.collect()
+	if err != nil {
+		return "", err
+	}
+	c.clusterState = state
+
+	results := c.runChecks()
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		if err := printOutput(c.newOutputList("CheckList", results), c.output, c.streams.Out); err != nil {
+			return "", err
+		}
+	} else {
+		c.outputResult(c.streams.Out, results)
+	}
+
+	return worstStatus(results), nil
+}
+
+func (c *checkCmd) outputResult(out io.Writer, results []checkResult) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	count := map[string]int{}
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+	for _, r := range results {
+		count[r.Status]++
+		details := append([]string{""}, r.Details...)
+		if len(r.Details) > 0 {
+			details = r.Details
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Status, details[0])
+		for _, d := range details[1:] {
+			fmt.Fprintf(w, "\t\t%s\n", d)
+		}
+	}
+
+	fmt.Fprintf(w, "\nResult: %s (%d failed, %d warnings, %d passed)\n",
+		worstStatus(results), count[checkFail], count[checkWarn], count[checkPass])
+}
+
+// runChecks runs all analyses of the collected information
+func (c *checkCmd) runChecks() []checkResult {
+	return []checkResult{
+		c.checkPods(),
+		c.checkClusterState(),
+		c.checkCoverage(),
+		c.checkSlotsView(),
+		c.checkReplicas(),
+		c.checkPlacement(),
+		c.checkOpenSlots(),
+		c.checkFailures(),
+		c.checkNodesViews(),
+		c.checkMembership(),
+		c.checkReplication(),
+		c.checkMemory(),
+		c.checkPersistence(),
+	}
+}
+
+// checkPods fails when no Redis instance could be queried, and warns when some could not
+func (c *checkCmd) checkPods() checkResult {
+	r := checkResult{Name: "pods reachable"}
+	if len(c.k8sInfo.Pods) == 0 {
+		r.Details = append(r.Details, "no pods found for the service")
+	}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; !ok {
+			r.Details = append(r.Details, fmt.Sprintf("%s: Redis unavailable", p.Name))
+		}
+		if p.Info != "" {
+			r.Details = append(r.Details, fmt.Sprintf("%s: %s", p.Name, p.Info))
+		}
+	}
+	switch {
+	case len(c.redisInfo) == 0:
+		r.Status = checkFail
+	case len(r.Details) > 0:
+		r.Status = checkWarn
+	default:
+		r.Status = checkPass
+	}
+	return r
+}
+
+// checkClusterState fails when a Redis instance reports that the cluster state is not ok
+func (c *checkCmd) checkClusterState() checkResult {
+	r := checkResult{Name: "cluster state", Status: checkPass}
+	for _, p := range c.podList() {
+		if state, ok := c.redisInfo[p.Name]["cluster_state"]; ok && state != "ok" {
+			r.Status = checkFail
+			r.Details = append(r.Details, fmt.Sprintf("%s: cluster_state is %s", p.Name, state))
+		}
+	}
+	return r
+}
+
+// checkCoverage fails when slots are not covered
+func (c *checkCmd) checkCoverage() checkResult {
+	r := checkResult{Name: "slot coverage", Status: checkPass}
+	slots, ok := c.redisSlots[c.slotsPodName()]
+	if !ok {
+		r.Status = checkFail
+		r.Details = append(r.Details, "no CLUSTER SLOTS view available")
+		return r
+	}
+	for _, u := range uncoveredSlots(slots) {
+		r.Details = append(r.Details, fmt.Sprintf("slots %s: uncovered", formatSlotRange(u.Start, u.End)))
+	}
+	if len(r.Details) > 0 {
+		r.Status = checkFail
+	}
+	return r
+}
+
+// checkSlotsView warns when pods have different CLUSTER SLOTS views
+func (c *checkCmd) checkSlotsView() checkResult {
+	r := checkResult{Name: "slots view", Status: checkPass}
+	for _, d := range c.slotsViewDiffs() {
+		r.Status = checkWarn
+		r.Details = append(r.Details, fmt.Sprintf("%s: differs from the view of %s", d.Pod, c.slotsPodName()))
+	}
+	return r
+}
+
+// checkReplicas warns when slot ranges lack replicas
+func (c *checkCmd) checkReplicas() checkResult {
+	r := checkResult{Name: "replicas", Status: checkPass}
+	for _, slot := range c.slotsWithGaps() {
+		if len(slot.Nodes) == 1 {
+			r.Status = checkWarn
+			r.Details = append(r.Details, fmt.Sprintf("slots %s: no replica of %s",
+				formatSlotRange(slot.Start, slot.End), c.addrName(slot.Nodes[0].Addr)))
+		}
+	}
+	return r
+}
+
+// checkPlacement warns when a K8s host or zone failure would lose slots or the majority of masters,
+// or when the zones are unknown and the zone checks are skipped
+func (c *checkCmd) checkPlacement() checkResult {
+	r := checkResult{Name: "placement", Status: checkPass}
+	for _, slot := range c.slotsWithGaps() {
+		remarks := analyzeSlotsInfo(slot, c.k8sInfo)
+		for _, remark := range []string{"*same host*", "*same zone*"} {
+			if strings.Contains(remarks, remark) {
+				r.Details = append(r.Details, fmt.Sprintf("slots %s: master and replicas on %s",
+					formatSlotRange(slot.Start, slot.End), strings.Trim(remark, "*")))
+			}
+		}
+	}
+	for _, z := range c.zoneMajorities() {
+		r.Details = append(r.Details, fmt.Sprintf("zone %s: holds %d of %d masters",
+			z.Zone, len(z.Masters), z.TotalMasters))
+	}
+	if c.k8sInfo.ZonesUnknown {
+		r.Details = append(r.Details, "zones unknown: failed to list K8s nodes, zone checks skipped")
+	}
+	if len(r.Details) > 0 {
+		r.Status = checkWarn
+	}
+	return r
+}
+
+// checkOpenSlots warns when slots are in migrating or importing state
+func (c *checkCmd) checkOpenSlots() checkResult {
+	r := checkResult{Name: "open slots", Status: checkPass}
+	for _, o := range c.openSlots() {
+		r.Status = checkWarn
+		r.Details = append(r.Details, fmt.Sprintf("slot %d: %s", o.Slot, o.describe()))
+	}
+	return r
+}
+
+// checkFailures fails when a pod is flagged as failing, and warns when a pod is suspected to fail
+func (c *checkCmd) checkFailures() checkResult {
+	r := checkResult{Name: "failure detection", Status: checkPass}
+	for _, row := range c.healthMatrix() {
+		for _, l := range row.Targets {
+			switch l.State {
+			case linkFail:
+				r.Status = checkFail
+			case linkPFail, linkDisconnected:
+				if r.Status == checkPass {
+					r.Status = checkWarn
+				}
+			default:
+				continue
+			}
+			r.Details = append(r.Details, fmt.Sprintf("%s: sees %s as %s", row.Pod, l.Pod, l.State))
+		}
+	}
+	return r
+}
+
+// checkNodesViews fails on split brain, and warns on config epoch collisions and partitions
+func (c *checkCmd) checkNodesViews() checkResult {
+	r := checkResult{Name: "cluster nodes", Status: checkPass}
+	analysis := c.analyzeClusterNodes()
+	if len(analysis.EpochCollisions) > 0 || len(analysis.Partitions) > 0 {
+		r.Status = checkWarn
+	}
+	if len(analysis.SlotConflicts) > 0 {
+		r.Status = checkFail
+	}
+	r.Details = analysis.findings()
+	return r
+}
+
+// checkMembership warns on cluster members without pods, and pods that are not members
+func (c *checkCmd) checkMembership() checkResult {
+	r := checkResult{Name: "membership", Status: checkPass}
+	membership := c.analyzeMembership()
+	if r.Details = membership.findings(); len(r.Details) > 0 {
+		r.Status = checkWarn
+	}
+	return r
+}
+
+// checkReplication warns when replicas are down, lagging, resyncing or unreachable
+func (c *checkCmd) checkReplication() checkResult {
+	r := checkResult{Name: "replication", Status: checkPass}
+	for _, info := range c.replicationInfos(c.maxLag) {
+		if len(info.Remarks) > 0 {
+			r.Status = checkWarn
+			r.Details = append(r.Details, fmt.Sprintf("%s: %s", info.Pod, strings.Join(info.Remarks, ", ")))
+		}
+	}
+	return r
+}
+
+// checkMemory warns when maxmemory is unset or above the container limit, or when the memory is nearly used
+func (c *checkCmd) checkMemory() checkResult {
+	r := checkResult{Name: "memory", Status: checkPass}
+	for _, info := range c.memoryInfos(defaultMemoryThreshold) {
+		if len(info.Remarks) > 0 {
+			r.Status = checkWarn
+			r.Details = append(r.Details, fmt.Sprintf("%s: %s", info.Pod, strings.Join(info.Remarks, ", ")))
+		}
+	}
+	return r
+}
+
+// checkPersistence warns when saves have failed, or when changes have never been persisted
+func (c *checkCmd) checkPersistence() checkResult {
+	r := checkResult{Name: "persistence", Status: checkPass}
+	for _, info := range c.persistenceInfos() {
+		if len(info.Remarks) > 0 {
+			r.Status = checkWarn
+			r.Details = append(r.Details, fmt.Sprintf("%s: %s", info.Pod, strings.Join(info.Remarks, ", ")))
+		}
+	}
+	return r
+}
+
+// worstStatus returns the most severe status of all checks
+func worstStatus(results []checkResult) string {
+	status := checkPass
+	for _, r := range results {
+		if checkExitCodes[r.Status] > checkExitCodes[status] {
+			status = r.Status
+		}
+	}
+	return status
+}
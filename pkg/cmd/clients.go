@@ -0,0 +1,323 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Output document of the clients command, with the client connections per address and per workload
+type clientsList struct {
+	outputList
+	ClientAddresses []clientAddress `json:"clientAddresses,omitempty"`
+	ClientOwners    []clientOwner   `json:"clientOwners,omitempty"`
+}
+
+type clientsCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	top         int
+
+	*clusterState
+}
+
+// Connections to a pod, with the number of connections per idle time
+type podClients struct {
+	Pod                string `json:"pod"`
+	Role               string `json:"role,omitempty"`
+	Connections        int    `json:"connections"`
+	IdleUnderMinute    int    `json:"idleUnderMinute"`
+	IdleUnderHour      int    `json:"idleUnderHour"`
+	IdleOverHour       int    `json:"idleOverHour"`
+	OutputMemory       int64  `json:"outputMemory"`
+	MaxOutputMemory    int64  `json:"maxOutputMemory"`
+	MaxOutputMemoryBy  string `json:"maxOutputMemoryBy,omitempty"`
+	OutputBufferedCmds int64  `json:"outputBufferedCmds"`
+}
+
+// Connections from a client address, and the pod behind it when known
+type clientAddress struct {
+	IP          string   `json:"ip"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Pod         string   `json:"pod,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Connections int      `json:"connections"`
+	RedisPods   []string `json:"redisPods"`
+}
+
+// Connections from the pods of a workload, like a deployment
+type clientOwner struct {
+	Namespace   string `json:"namespace"`
+	Owner       string `json:"owner"`
+	Pods        int    `json:"pods"`
+	Connections int    `json:"connections"`
+}
+
+// NewClientsCmd initialize and creates a Cobra command
+func NewClientsCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &clientsCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "clients [service-name] [flags]",
+		Short: "Show the client connections of a Redis Cluster and the pods behind them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().IntVar(&c.top, "top", 10, "Number of client addresses and owners to show")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *clientsCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *clientsCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.top <= 0 {
+		return fmt.Errorf("top must be a positive number, got %d", c.top)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *clientsCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+	// Reuse the connections of the collect when running the commands on the pods
+	query.keepConnections = true
+	defer query.close()
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	refs, err := getPodRefs(query.restConfig, query.namespace, c.streams.ErrOut)
+	if err != nil {
+		return err
+	}
+
+	clients := c.fetchClients(query)
+	pods := c.podClientsList(clients)
+	addresses := clientAddresses(clients, refs)
+	owners := clientOwners(addresses)
+	if len(addresses) > c.top {
+		addresses = addresses[:c.top]
+	}
+	if len(owners) > c.top {
+		owners = owners[:c.top]
+	}
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		list := clientsList{
+			outputList:      *c.newOutputList("ClientsList", pods),
+			ClientAddresses: addresses,
+			ClientOwners:    owners,
+		}
+		return printOutput(list, c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out, pods, addresses, owners)
+
+	return nil
+}
+
+func (c *clientsCmd) outputResult(out io.Writer, pods []podClients, addresses []clientAddress, owners []clientOwner) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\t\t\tIDLE\tIDLE\tIDLE\tOUTPUT\tOUTPUT\tOUTPUT\tMAX OUTPUT")
+	fmt.Fprintln(w, "PODNAME\tROLE\tCLIENTS\t<1M\t<1H\t>1H\tMEMORY\tMAX\tLIST\tCLIENT")
+	for _, p := range pods {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%d\t%s\n",
+			p.Pod, p.Role, p.Connections, p.IdleUnderMinute, p.IdleUnderHour, p.IdleOverHour,
+			formatBytes(p.OutputMemory), formatBytes(p.MaxOutputMemory), p.OutputBufferedCmds, p.MaxOutputMemoryBy)
+	}
+
+	if len(addresses) > 0 {
+		fmt.Fprintf(w, "\nTop client addresses:\n")
+		fmt.Fprintln(w, "ADDRESS\tNAMESPACE\tCLIENT POD\tOWNER\tCONNECTIONS\tREDIS PODS")
+		for _, a := range addresses {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n",
+				a.IP, a.Namespace, a.Pod, a.Owner, a.Connections, len(a.RedisPods))
+		}
+	}
+
+	if len(owners) > 0 {
+		fmt.Fprintf(w, "\nTop client owners:\n")
+		fmt.Fprintln(w, "NAMESPACE\tOWNER\tPODS\tCONNECTIONS")
+		for _, o := range owners {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", o.Namespace, o.Owner, o.Pods, o.Connections)
+		}
+	}
+
+	c.outputErrors(w)
+}
+
+// fetchClients fetches the client connections of all pods in parallel, given per pod name
+func (c *clientsCmd) fetchClients(query *clusterQuery) map[string][]redisutils.ClientInfo {
+	pods := []k8s.PodInfo{}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; ok {
+			pods = append(pods, p)
+		}
+	}
+	results := query.runOnPods(pods, func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error) {
+		return conn.ClientList()
+	})
+
+	clients := map[string][]redisutils.ClientInfo{}
+	for _, r := range results {
+		if r.err != nil {
+			c.errors[r.pod.Name] = append(c.errors[r.pod.Name], fmt.Sprintf("client list failed: %v", r.err))
+			continue
+		}
+		clients[r.pod.Name] = r.value.([]redisutils.ClientInfo)
+	}
+	return clients
+}
+
+// podClientsList returns a summary of the connections to each pod
+func (s *clusterState) podClientsList(clients map[string][]redisutils.ClientInfo) []podClients {
+	result := []podClients{}
+	for _, p := range s.podList() {
+		list, ok := clients[p.Name]
+		if !ok {
+			continue
+		}
+		pc := podClients{Pod: p.Name, Role: s.podRole(p.Name), Connections: len(list)}
+		for _, client := range list {
+			switch {
+			case client.Idle < 60:
+				pc.IdleUnderMinute++
+			case client.Idle < 60*60:
+				pc.IdleUnderHour++
+			default:
+				pc.IdleOverHour++
+			}
+			pc.OutputMemory += client.OutputMemory
+			pc.OutputBufferedCmds += client.OutputListLength
+			if client.OutputMemory > pc.MaxOutputMemory {
+				pc.MaxOutputMemory = client.OutputMemory
+				pc.MaxOutputMemoryBy = client.Addr
+			}
+		}
+		result = append(result, pc)
+	}
+	return result
+}
+
+// clientAddresses returns the connections per client IP, most connections first
+func clientAddresses(clients map[string][]redisutils.ClientInfo, refs k8s.PodRefs) []clientAddress {
+	addresses := map[string]*clientAddress{}
+	for pod, list := range clients {
+		for _, client := range list {
+			ip := client.IP()
+			a, found := addresses[ip]
+			if !found {
+				ref := refs[ip]
+				a = &clientAddress{IP: ip, Namespace: ref.Namespace, Pod: ref.Name, Owner: ref.Owner}
+				addresses[ip] = a
+			}
+			a.Connections++
+			if !contains(a.RedisPods, pod) {
+				a.RedisPods = append(a.RedisPods, pod)
+			}
+		}
+	}
+
+	result := []clientAddress{}
+	for _, a := range addresses {
+		sort.Strings(a.RedisPods)
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Connections != result[j].Connections {
+			return result[i].Connections > result[j].Connections
+		}
+		return result[i].IP < result[j].IP
+	})
+	return result
+}
+
+// clientOwners returns the connections per workload, most connections first.
+// Pods without an owner are shown as their own workload, unknown addresses are skipped.
+func clientOwners(addresses []clientAddress) []clientOwner {
+	owners := map[string]*clientOwner{}
+	for _, a := range addresses {
+		if a.Pod == "" {
+			continue
+		}
+		owner := a.Owner
+		if owner == "" {
+			owner = "pod/" + a.Pod
+		}
+		key := a.Namespace + "/" + owner
+		o, found := owners[key]
+		if !found {
+			o = &clientOwner{Namespace: a.Namespace, Owner: owner}
+			owners[key] = o
+		}
+		o.Pods++
+		o.Connections += a.Connections
+	}
+
+	result := []clientOwner{}
+	for _, o := range owners {
+		result = append(result, *o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Connections != result[j].Connections {
+			return result[i].Connections > result[j].Connections
+		}
+		return result[i].Namespace+"/"+result[i].Owner < result[j].Namespace+"/"+result[j].Owner
+	})
+	return result
+}
@@ -3,6 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"sync"
 
 	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
 	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
@@ -21,6 +24,134 @@ type QueryRedisResult struct {
 	Error   error
 }
 
+// Information collected from K8s and from all Redis instances
+type clusterState struct {
+	namespace   string
+	serviceName string
+
+	k8sInfo    *k8s.ClusterInfo
+	redisInfo  map[string]redisutils.RedisInfo
+	redisSlots map[string]redisutils.ClusterSlots
+	redisNodes map[string]redisutils.ClusterNodes
+	remarks    map[string][]string
+	errors     map[string][]string
+
+	// The authoritative CLUSTER SLOTS view, found when first needed
+	slotsView *slotsViewOwner
+}
+
+func newClusterState() *clusterState {
+	return &clusterState{
+		k8sInfo:    k8s.NewClusterInfo(),
+		redisInfo:  make(map[string]redisutils.RedisInfo),
+		redisSlots: make(map[string]redisutils.ClusterSlots),
+		redisNodes: make(map[string]redisutils.ClusterNodes),
+		remarks:    make(map[string][]string),
+		errors:     make(map[string][]string),
+	}
+}
+
+// podList returns all pods sorted by host and ip
+func (s *clusterState) podList() []k8s.PodInfo {
+	podList := []k8s.PodInfo{}
+	for _, pod := range s.k8sInfo.Pods {
+		podList = append(podList, pod)
+	}
+
+	sort.Slice(podList, func(i, j int) bool {
+		if podList[i].Host != podList[j].Host {
+			return podList[i].Host < podList[j].Host
+		}
+		return podList[i].IP < podList[j].IP
+	})
+	return podList
+}
+
+// podListByName returns all pods sorted by name, giving a stable order in the machine-readable output
+func (s *clusterState) podListByName() []k8s.PodInfo {
+	podList := s.podList()
+	sort.Slice(podList, func(i, j int) bool {
+		return podList[i].Name < podList[j].Name
+	})
+	return podList
+}
+
+// outputErrors prints the errors of all pods, preceded by an empty line
+func (s *clusterState) outputErrors(w io.Writer) {
+	addNewline := true
+	for _, p := range s.podList() {
+		for _, text := range s.errors[p.Name] {
+			if addNewline {
+				fmt.Fprintf(w, "\n")
+				addNewline = false
+			}
+			fmt.Fprintf(w, "%s:\t%s\n", p.Name, text)
+		}
+	}
+}
+
+// masterPodName returns the pod name of the master that a replica pod follows.
+// The master ID is returned when the pod is unknown, or an empty string for a master.
+func (s *clusterState) masterPodName(podName string) string {
+	nodes := s.redisNodes[podName]
+	self, found := nodes.GetSelf()
+	if !found || self.MasterID == "" {
+		return ""
+	}
+
+	return s.nodePodName(podName, self.MasterID)
+}
+
+// slotsPodName returns the pod which CLUSTER SLOTS view is presented,
+// which is the view shared by most pods
+func (s *clusterState) slotsPodName() string {
+	podName, _ := s.authoritativeSlotsPod()
+	return podName
+}
+
+// slotOwners returns the pod name of the master of each slot, or the
+// address when the pod is unknown. Returns nil when no slots view exists.
+func (s *clusterState) slotOwners() []string {
+	slots, ok := s.redisSlots[s.slotsPodName()]
+	if !ok {
+		return nil
+	}
+
+	owners := make([]string, redisutils.SlotCount)
+	for _, slot := range slots {
+		if len(slot.Nodes) == 0 {
+			continue
+		}
+		owner := s.k8sInfo.GetPodInfo(slot.Nodes[0].Addr).Name
+		if owner == "" {
+			owner = slot.Nodes[0].Addr
+		}
+		for i := slot.Start; i <= slot.End && i < redisutils.SlotCount; i++ {
+			owners[i] = owner
+		}
+	}
+	return owners
+}
+
+// addQueryResult stores the result from one pod/redis instance
+func (s *clusterState) addQueryResult(queryResult QueryRedisResult) {
+	if queryResult.Error != nil {
+		pod := queryResult.PodName
+		s.remarks[pod] = append(s.remarks[pod], "RedisUnavailable")
+		s.errors[pod] = append(s.errors[pod],
+			fmt.Sprintf("Failed to get Redis information: %s", queryResult.Error))
+	}
+	if queryResult.Info != nil {
+		s.redisInfo[queryResult.PodName] = queryResult.Info
+	}
+	if queryResult.Nodes != nil {
+		s.redisNodes[queryResult.PodName] = queryResult.Nodes
+	}
+	if queryResult.Slots != nil {
+		s.redisSlots[queryResult.PodName] = queryResult.Slots
+	}
+}
+
 func getK8sInfo(restConfig *rest.Config, serviceName string, namespace string, k8sInfo *k8s.ClusterInfo) error {
 	clientset := kubernetes.NewForConfigOrDie(restConfig)
 
@@ -56,7 +187,64 @@ func getK8sInfo(restConfig *rest.Config, serviceName string, namespace string, k
 	if err != nil {
 		return fmt.Errorf("failed to list pods in namespace/%s: %v", namespace, err)
 	}
-	k8sInfo.UpdatePods(pods)
+	k8sInfo.UpdatePods(pods, redisutils.RedisPort)
 
 	return nil
 }
+
+// The warning for not being allowed to list K8s nodes is only given once, also when watching
+var zonesWarning sync.Once
+
+// getZoneInfo gets the zone and region of the K8s hosts. Listing nodes requires
+// cluster wide permissions, so the zone checks are skipped when not allowed.
+func getZoneInfo(restConfig *rest.Config, k8sInfo *k8s.ClusterInfo, errOut io.Writer) {
+	clientset := kubernetes.NewForConfigOrDie(restConfig)
+
+	var timeout int64 = 2
+	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{TimeoutSeconds: &timeout})
+	if err != nil {
+		k8sInfo.ZonesUnknown = true
+		zonesWarning.Do(func() {
+			fmt.Fprintf(errOut, "Warning: failed to list K8s nodes, skipping the zone checks: %v\n", err)
+		})
+		return
+	}
+	k8sInfo.UpdateNodes(nodes)
+}
+
+// Number of pods per request when listing the pods of all namespaces
+const podListPageSize = 500
+
+// getPodRefs lists the pods in all namespaces, used to find the pods behind client addresses.
+// Listing pods in all namespaces requires cluster wide permissions, so only the pods in the
+// given namespace are listed, with a warning, when not allowed.
+func getPodRefs(restConfig *rest.Config, namespace string, errOut io.Writer) (k8s.PodRefs, error) {
+	clientset := kubernetes.NewForConfigOrDie(restConfig)
+
+	refs := k8s.PodRefs{}
+	if err := listPodRefs(clientset, metav1.NamespaceAll, refs); err != nil {
+		fmt.Fprintf(errOut, "Warning: failed to list pods in all namespaces, only clients in namespace/%s are resolved: %v\n",
+			namespace, err)
+		refs = k8s.PodRefs{}
+		if err := listPodRefs(clientset, namespace, refs); err != nil {
+			return nil, fmt.Errorf("failed to list pods in namespace/%s: %v", namespace, err)
+		}
+	}
+	return refs, nil
+}
+
+// listPodRefs adds the pods of a namespace, or all namespaces, fetched in pages
+func listPodRefs(clientset *kubernetes.Clientset, namespace string, refs k8s.PodRefs) error {
+	options := metav1.ListOptions{Limit: podListPageSize}
+	for {
+		pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), options)
+		if err != nil {
+			return err
+		}
+		refs.UpdatePods(pods)
+		if pods.Continue == "" {
+			return nil
+		}
+		options.Continue = pods.Continue
+	}
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Parameters that are expected to differ between nodes
+var nodeSpecificConfig = []string{
+	"cluster-announce-ip", "cluster-announce-hostname", "cluster-announce-human-nodename",
+	"cluster-announce-port", "cluster-announce-bus-port", "cluster-announce-tls-port",
+	"replicaof", "slaveof",
+}
+
+// Parameters holding secrets, the values are not shown
+var secretConfig = []string{"requirepass", "masterauth", "tls-key-file-pass", "tls-client-key-file-pass"}
+
+type configDiffCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+
+	*clusterState
+}
+
+// A configuration parameter with different values between the pods
+type configDiff struct {
+	Parameter string        `json:"parameter"`
+	Values    []configValue `json:"values"`
+}
+
+// A value of a configuration parameter, and the pods of a role using it
+type configValue struct {
+	Role  string   `json:"role"`
+	Value string   `json:"value"`
+	Pods  []string `json:"pods"`
+}
+
+// NewConfigCmd initialize and creates a Cobra command
+func NewConfigCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show the configuration of a Redis Cluster",
+	}
+	cmd.AddCommand(newConfigDiffCmd(streams))
+	return cmd
+}
+
+func newConfigDiffCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &configDiffCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff [service-name] [flags]",
+		Short: "Show the configuration parameters that differ between the pods, grouped by role",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *configDiffCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *configDiffCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *configDiffCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+	// Reuse the connections of the collect when running the commands on the pods
+	query.keepConnections = true
+	defer query.close()
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	diffs := c.configDiffs(c.fetchConfigs(query))
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return printOutput(c.newOutputList("ConfigDiffList", diffs), c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out, diffs)
+
+	return nil
+}
+
+func (c *configDiffCmd) outputResult(out io.Writer, diffs []configDiff) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No configuration differences between the pods")
+	}
+
+	// One section per role, masters first
+	roles := []string{}
+	for _, d := range diffs {
+		for _, v := range d.Values {
+			if !contains(roles, v.Role) {
+				roles = append(roles, v.Role)
+			}
+		}
+	}
+	sort.Strings(roles)
+
+	for i, role := range roles {
+		if i > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "Role %s:\n", role)
+		fmt.Fprintln(w, "PARAMETER\tVALUE\tPODS")
+		for _, d := range diffs {
+			for _, v := range d.Values {
+				if v.Role != role {
+					continue
+				}
+				value := v.Value
+				if value == "" {
+					value = `""`
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", d.Parameter, value, strings.Join(v.Pods, ", "))
+			}
+		}
+	}
+
+	c.outputErrors(w)
+}
+
+// fetchConfigs fetches all configuration parameters of all pods in parallel, given per pod name
+func (c *configDiffCmd) fetchConfigs(query *clusterQuery) map[string]map[string]string {
+	pods := []k8s.PodInfo{}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; ok {
+			pods = append(pods, p)
+		}
+	}
+	results := query.runOnPods(pods, func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error) {
+		return conn.ConfigGetAll()
+	})
+
+	configs := map[string]map[string]string{}
+	for _, r := range results {
+		if r.err != nil {
+			c.errors[r.pod.Name] = append(c.errors[r.pod.Name], fmt.Sprintf("config get failed: %v", r.err))
+			continue
+		}
+		configs[r.pod.Name] = r.value.(map[string]string)
+	}
+	return configs
+}
+
+// configDiffs returns the parameters with different values between the pods, ordered by name.
+// A parameter missing in a pod, like when running another Redis version, is shown as an empty value.
+// Parameters expected to differ between nodes are skipped, and secrets are replaced by a placeholder.
+func (s *clusterState) configDiffs(configs map[string]map[string]string) []configDiff {
+	parameters := []string{}
+	seen := map[string]bool{}
+	for _, config := range configs {
+		for param := range config {
+			if !seen[param] && !contains(nodeSpecificConfig, param) {
+				seen[param] = true
+				parameters = append(parameters, param)
+			}
+		}
+	}
+	sort.Strings(parameters)
+
+	result := []configDiff{}
+	for _, param := range parameters {
+		values := map[string]bool{}
+		for _, config := range configs {
+			values[config[param]] = true
+		}
+		if len(values) < 2 {
+			continue
+		}
+
+		// Number the secrets by first appearance, to show which pods share a value
+		secrets := map[string]int{}
+		diff := configDiff{Parameter: param, Values: []configValue{}}
+		for _, p := range s.podList() {
+			config, ok := configs[p.Name]
+			if !ok {
+				continue
+			}
+			value := config[param]
+			if contains(secretConfig, param) && value != "" {
+				if _, found := secrets[value]; !found {
+					secrets[value] = len(secrets) + 1
+				}
+				value = fmt.Sprintf("<hidden value %d>", secrets[value])
+			}
+			role := s.podRole(p.Name)
+			if role == "" {
+				role = "unknown"
+			}
+
+			added := false
+			for i := range diff.Values {
+				if diff.Values[i].Role == role && diff.Values[i].Value == value {
+					diff.Values[i].Pods = append(diff.Values[i].Pods, p.Name)
+					added = true
+				}
+			}
+			if !added {
+				diff.Values = append(diff.Values, configValue{Role: role, Value: value, Pods: []string{p.Name}})
+			}
+		}
+		sort.SliceStable(diff.Values, func(i, j int) bool {
+			return diff.Values[i].Role < diff.Values[j].Role
+		})
+		result = append(result, diff)
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testConfigs returns captured CONFIG GET * replies of all pods, shortened to a few parameters
+func testConfigs() map[string]map[string]string {
+	configs := map[string]map[string]string{}
+	for _, p := range testPods {
+		configs[p.Name] = map[string]string{
+			"appendonly":          "no",
+			"cluster-announce-ip": p.IP,
+			"maxmemory":           "0",
+			"maxmemory-policy":    "noeviction",
+			"masterauth":          "",
+			"requirepass":         "",
+		}
+	}
+	return configs
+}
+
+func TestConfigDiffs(t *testing.T) {
+	masters := []string{"rediscluster-cluster-7tpnv", "rediscluster-cluster-dqrzl", "rediscluster-cluster-lsx6p"}
+	replicas := []string{"rediscluster-cluster-xw8hn", "rediscluster-cluster-m2b7n", "rediscluster-cluster-qfjz4"}
+
+	tests := []struct {
+		name   string
+		change func(s *clusterState, configs map[string]map[string]string)
+		want   []configDiff
+	}{
+		{
+			name:   "only node specific differences",
+			change: func(s *clusterState, configs map[string]map[string]string) {},
+			want:   []configDiff{},
+		},
+		{
+			name: "different value on a replica",
+			change: func(s *clusterState, configs map[string]map[string]string) {
+				configs["rediscluster-cluster-m2b7n"]["maxmemory-policy"] = "allkeys-lru"
+			},
+			want: []configDiff{{
+				Parameter: "maxmemory-policy",
+				Values: []configValue{
+					{Role: "master", Value: "noeviction", Pods: masters},
+					{Role: "replica", Value: "noeviction",
+						Pods: []string{"rediscluster-cluster-xw8hn", "rediscluster-cluster-qfjz4"}},
+					{Role: "replica", Value: "allkeys-lru", Pods: []string{"rediscluster-cluster-m2b7n"}},
+				},
+			}},
+		},
+		{
+			name: "secrets hidden",
+			change: func(s *clusterState, configs map[string]map[string]string) {
+				for _, name := range masters {
+					configs[name]["requirepass"] = "s3cret"
+				}
+				for _, name := range replicas[:2] {
+					configs[name]["requirepass"] = "0ld-s3cret"
+				}
+			},
+			want: []configDiff{{
+				Parameter: "requirepass",
+				Values: []configValue{
+					{Role: "master", Value: "<hidden value 1>", Pods: masters},
+					{Role: "replica", Value: "<hidden value 2>", Pods: replicas[:2]},
+					{Role: "replica", Value: "", Pods: replicas[2:]},
+				},
+			}},
+		},
+		{
+			name: "parameter missing in another version",
+			change: func(s *clusterState, configs map[string]map[string]string) {
+				for _, name := range masters[1:] {
+					configs[name]["latency-tracking"] = "yes"
+				}
+			},
+			want: []configDiff{{
+				Parameter: "latency-tracking",
+				Values: []configValue{
+					{Role: "master", Value: "", Pods: masters[:1]},
+					{Role: "master", Value: "yes", Pods: masters[1:]},
+					{Role: "replica", Value: "", Pods: replicas},
+				},
+			}},
+		},
+		{
+			name: "pod with unknown role",
+			change: func(s *clusterState, configs map[string]map[string]string) {
+				delete(s.redisNodes, "rediscluster-cluster-qfjz4")
+				delete(configs, "rediscluster-cluster-xw8hn")
+				configs["rediscluster-cluster-qfjz4"]["appendonly"] = "yes"
+			},
+			want: []configDiff{{
+				Parameter: "appendonly",
+				Values: []configValue{
+					{Role: "master", Value: "no", Pods: masters},
+					{Role: "replica", Value: "no", Pods: []string{"rediscluster-cluster-m2b7n"}},
+					{Role: "unknown", Value: "yes", Pods: []string{"rediscluster-cluster-qfjz4"}},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestState()
+			configs := testConfigs()
+			tt.change(s, configs)
+			if got := s.configDiffs(configs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
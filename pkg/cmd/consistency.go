@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+)
+
+// slotsViewDiff describes how the CLUSTER SLOTS view of a pod differs from the authoritative view
+type slotsViewDiff struct {
+	Pod         string   `json:"pod"`
+	Differences []string `json:"differences"`
+}
+
+// The pod presenting the authoritative CLUSTER SLOTS view, and the number of pods sharing it
+type slotsViewOwner struct {
+	pod    string
+	shared int
+}
+
+// The master and replicas of a single slot
+type slotEntry struct {
+	master   string
+	replicas string
+}
+
+// slotsViewKey returns a comparable representation of a CLUSTER SLOTS view,
+// where the order of the replicas of a slot range is ignored
+func slotsViewKey(slots redisutils.ClusterSlots) string {
+	b := &strings.Builder{}
+	for _, slot := range slots {
+		fmt.Fprintf(b, "%d-%d", slot.Start, slot.End)
+		if len(slot.Nodes) > 0 {
+			replicas := []string{}
+			for _, node := range slot.Nodes[1:] {
+				replicas = append(replicas, node.Addr)
+			}
+			sort.Strings(replicas)
+			fmt.Fprintf(b, ",%s", slot.Nodes[0].Addr)
+			for _, addr := range replicas {
+				fmt.Fprintf(b, ",%s", addr)
+			}
+		}
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// authoritativeSlotsPod returns the pod which CLUSTER SLOTS view is shared by most pods,
+// together with the number of pods sharing it. The lowest pod name wins a tie.
+// The result is kept since the collected views don't change.
+func (s *clusterState) authoritativeSlotsPod() (string, int) {
+	if s.slotsView == nil {
+		podName, shared := s.findAuthoritativeSlotsPod()
+		s.slotsView = &slotsViewOwner{pod: podName, shared: shared}
+	}
+	return s.slotsView.pod, s.slotsView.shared
+}
+
+func (s *clusterState) findAuthoritativeSlotsPod() (string, int) {
+	views := map[string][]string{}
+	for podName, slots := range s.redisSlots {
+		key := slotsViewKey(slots)
+		views[key] = append(views[key], podName)
+	}
+
+	podName := ""
+	shared := 0
+	for _, pods := range views {
+		sort.Strings(pods)
+		if len(pods) > shared || (len(pods) == shared && pods[0] < podName) {
+			podName = pods[0]
+			shared = len(pods)
+		}
+	}
+	return podName, shared
+}
+
+// slotsViewDiffs compares the CLUSTER SLOTS view of each pod with the authoritative view
+func (s *clusterState) slotsViewDiffs() []slotsViewDiff {
+	result := []slotsViewDiff{}
+	authPod := s.slotsPodName()
+	if authPod == "" {
+		return result
+	}
+	authKey := slotsViewKey(s.redisSlots[authPod])
+	authEntries := slotEntries(s.redisSlots[authPod])
+
+	for _, p := range s.podList() {
+		slots, ok := s.redisSlots[p.Name]
+		if !ok || slotsViewKey(slots) == authKey {
+			continue
+		}
+		diff := slotsViewDiff{Pod: p.Name, Differences: s.compareSlotEntries(slotEntries(slots), authEntries)}
+		if len(diff.Differences) == 0 {
+			// Same slot ownership, but presented in different ranges
+			diff.Differences = append(diff.Differences, "different slot range boundaries")
+		}
+		result = append(result, diff)
+	}
+	return result
+}
+
+// slotEntries expands a CLUSTER SLOTS view to one entry per slot
+func slotEntries(slots redisutils.ClusterSlots) []slotEntry {
+	entries := make([]slotEntry, redisutils.SlotCount)
+	for _, slot := range slots {
+		if len(slot.Nodes) == 0 {
+			continue
+		}
+		replicas := []string{}
+		for _, node := range slot.Nodes[1:] {
+			replicas = append(replicas, node.Addr)
+		}
+		sort.Strings(replicas)
+
+		entry := slotEntry{master: slot.Nodes[0].Addr, replicas: strings.Join(replicas, ",")}
+		for i := slot.Start; i <= slot.End && i < redisutils.SlotCount; i++ {
+			entries[i] = entry
+		}
+	}
+	return entries
+}
+
+// A difference for a single slot, described as "<what> <slot range><detail>"
+type slotDiff struct {
+	what   string
+	detail string
+}
+
+// compareSlotEntries describes the differences, grouped in slot ranges
+func (s *clusterState) compareSlotEntries(entries []slotEntry, authEntries []slotEntry) []string {
+	result := []string{}
+	start := 0
+	current := slotDiff{}
+	for slot := 0; slot <= len(entries); slot++ {
+		diff := slotDiff{}
+		if slot < len(entries) {
+			diff = s.describeSlotDiff(entries[slot], authEntries[slot])
+		}
+		if diff != current {
+			if current.what != "" {
+				result = append(result, fmt.Sprintf("%s %s%s",
+					current.what, formatSlotRange(start, slot-1), current.detail))
+			}
+			start = slot
+			current = diff
+		}
+	}
+	return result
+}
+
+// describeSlotDiff returns the difference of a slot, which is empty when equal
+func (s *clusterState) describeSlotDiff(entry slotEntry, auth slotEntry) slotDiff {
+	switch {
+	case entry == auth:
+		return slotDiff{}
+	case auth.master == "":
+		return slotDiff{"extra range", fmt.Sprintf(" served by %s", s.addrName(entry.master))}
+	case entry.master == "":
+		return slotDiff{"missing range", ""}
+	case entry.master != auth.master:
+		return slotDiff{"different owner for slots", fmt.Sprintf(": %s, expected %s",
+			s.addrName(entry.master), s.addrName(auth.master))}
+	}
+	return slotDiff{"stale replica list for slots", fmt.Sprintf(": [%s], expected [%s]",
+		s.addrNames(entry.replicas), s.addrNames(auth.replicas))}
+}
+
+// addrName returns the pod name of an address, or the address when the pod is unknown
+func (s *clusterState) addrName(addr string) string {
+	if name := s.k8sInfo.GetPodInfo(addr).Name; name != "" {
+		return name
+	}
+	return addr
+}
+
+// addrNames returns the pod names of comma separated addresses
+func (s *clusterState) addrNames(addrs string) string {
+	if addrs == "" {
+		return ""
+	}
+	names := []string{}
+	for _, addr := range strings.Split(addrs, ",") {
+		names = append(names, s.addrName(addr))
+	}
+	return strings.Join(names, ",")
+}
+
+func formatSlotRange(start int, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestCompareSlotEntries(t *testing.T) {
+	master1 := redis.ClusterNode{ID: idMaster1, Addr: "10.244.1.5:6379"}
+	master2 := redis.ClusterNode{ID: idMaster2, Addr: "10.244.2.5:6379"}
+	master3 := redis.ClusterNode{ID: idMaster3, Addr: "10.244.3.5:6379"}
+	replica1 := redis.ClusterNode{ID: idReplica1, Addr: "10.244.2.6:6379"}
+	replica2 := redis.ClusterNode{ID: idReplica2, Addr: "10.244.3.6:6379"}
+	replica3 := redis.ClusterNode{ID: idReplica3, Addr: "10.244.1.6:6379"}
+	unknown := redis.ClusterNode{ID: "f4a6b4ed4d1c5f2a4b21b0a4b2a4bdf49b5f0b06", Addr: "10.244.4.5:6379"}
+
+	tests := []struct {
+		name  string
+		slots redisutils.ClusterSlots
+		auth  redisutils.ClusterSlots
+		want  []string
+	}{
+		{
+			name:  "same view",
+			slots: testClusterSlots(),
+			want:  []string{},
+		},
+		{
+			name: "same view in other ranges",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 1000, Nodes: []redis.ClusterNode{master1, replica1}},
+				{Start: 1001, End: 5460, Nodes: []redis.ClusterNode{master1, replica1}},
+				{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{master2, replica2}},
+				{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{master3, replica3}},
+			},
+			want: []string{},
+		},
+		{
+			name: "missing and different owner",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1, replica1}},
+				{Start: 5461, End: 5470, Nodes: []redis.ClusterNode{master1, replica1}},
+				{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{master3, replica3}},
+			},
+			want: []string{
+				"different owner for slots 5461-5470: rediscluster-cluster-7tpnv, expected rediscluster-cluster-dqrzl",
+				"missing range 5471-10922",
+			},
+		},
+		{
+			name: "stale replica list",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1}},
+				{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{master2, replica2}},
+				{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{master3, replica3, replica1}},
+			},
+			want: []string{
+				"stale replica list for slots 0-5460: [], expected [rediscluster-cluster-m2b7n]",
+				"stale replica list for slots 10923-16383: [rediscluster-cluster-xw8hn,rediscluster-cluster-m2b7n], " +
+					"expected [rediscluster-cluster-xw8hn]",
+			},
+		},
+		{
+			name: "extra range",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1, replica1}},
+				{Start: 16000, End: 16383, Nodes: []redis.ClusterNode{unknown}},
+			},
+			auth: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1, replica1}},
+			},
+			want: []string{"extra range 16000-16383 served by 10.244.4.5:6379"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := tt.auth
+			if auth == nil {
+				auth = testClusterSlots()
+			}
+			s := newTestState()
+			got := s.compareSlotEntries(slotEntries(tt.slots), slotEntries(auth))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotsViewDiffs(t *testing.T) {
+	master1 := redis.ClusterNode{ID: idMaster1, Addr: "10.244.1.5:6379"}
+	replica1 := redis.ClusterNode{ID: idReplica1, Addr: "10.244.2.6:6379"}
+
+	tests := []struct {
+		name   string
+		change func(s *clusterState)
+		want   []slotsViewDiff
+	}{
+		{
+			name:   "same views",
+			change: func(s *clusterState) {},
+			want:   []slotsViewDiff{},
+		},
+		{
+			name: "replicas in other order",
+			change: func(s *clusterState) {
+				replica := redis.ClusterNode{ID: idReplica3, Addr: "10.244.1.6:6379"}
+				slots := testClusterSlots()
+				slots[0].Nodes = append(slots[0].Nodes, replica)
+				s.setClusterSlots(slots)
+
+				slots = testClusterSlots()
+				slots[0].Nodes = []redis.ClusterNode{slots[0].Nodes[0], replica, slots[0].Nodes[1]}
+				s.redisSlots["rediscluster-cluster-qfjz4"] = slots
+			},
+			want: []slotsViewDiff{},
+		},
+		{
+			name: "stale view",
+			change: func(s *clusterState) {
+				slots := testClusterSlots()
+				slots[1].Start = 5000
+				slots[0] = redis.ClusterSlot{Start: 0, End: 4999, Nodes: []redis.ClusterNode{master1, replica1}}
+				s.redisSlots["rediscluster-cluster-xw8hn"] = slots
+			},
+			want: []slotsViewDiff{{
+				Pod: "rediscluster-cluster-xw8hn",
+				Differences: []string{
+					"different owner for slots 5000-5460: rediscluster-cluster-dqrzl, expected rediscluster-cluster-7tpnv",
+				},
+			}},
+		},
+		{
+			name: "other range boundaries",
+			change: func(s *clusterState) {
+				slots := testClusterSlots()
+				slots = append(redisutils.ClusterSlots{
+					{Start: 0, End: 99, Nodes: []redis.ClusterNode{master1, replica1}},
+					{Start: 100, End: 5460, Nodes: []redis.ClusterNode{master1, replica1}},
+				}, slots[1:]...)
+				s.redisSlots["rediscluster-cluster-dqrzl"] = slots
+			},
+			want: []slotsViewDiff{{
+				Pod:         "rediscluster-cluster-dqrzl",
+				Differences: []string{"different slot range boundaries"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestState()
+			tt.change(s)
+			if got := s.slotsViewDiffs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// A range of slots, inclusive
+type slotRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// uncoveredSlots finds the slot ranges that are not covered in a CLUSTER SLOTS view.
+// A single view never has overlapping ranges since Redis builds it from one slot table,
+// masters claiming the same slots are instead found by comparing the CLUSTER NODES views.
+func uncoveredSlots(slots redisutils.ClusterSlots) []slotRange {
+	covered := make([]bool, redisutils.SlotCount)
+	for _, slot := range slots {
+		for i := slot.Start; i <= slot.End && i < redisutils.SlotCount; i++ {
+			if i >= 0 {
+				covered[i] = true
+			}
+		}
+	}
+
+	uncovered := []slotRange{}
+	for i := 0; i < redisutils.SlotCount; i++ {
+		if covered[i] {
+			continue
+		}
+		// Extend the last range when continuous
+		if n := len(uncovered); n > 0 && uncovered[n-1].End == i-1 {
+			uncovered[n-1].End = i
+		} else {
+			uncovered = append(uncovered, slotRange{Start: i, End: i})
+		}
+	}
+	return uncovered
+}
+
+// slotsWithGaps returns the shown CLUSTER SLOTS view, with the uncovered
+// slot ranges added as ranges without nodes
+func (s *clusterState) slotsWithGaps() redisutils.ClusterSlots {
+	slots, ok := s.redisSlots[s.slotsPodName()]
+	if !ok {
+		return redisutils.ClusterSlots{}
+	}
+	uncovered := uncoveredSlots(slots)
+
+	result := append(redisutils.ClusterSlots{}, slots...)
+	for _, r := range uncovered {
+		result = append(result, redis.ClusterSlot{Start: r.Start, End: r.End})
+	}
+	sort.Stable(redisutils.BySlot(result))
+	return result
+}
+
+// slotRangeRemarks returns the remarks of a slot range, given the zone majorities of the shown view
+func (s *clusterState) slotRangeRemarks(slot redis.ClusterSlot, zones []zoneMajority) []string {
+	remarks := []string{}
+	if r := analyzeSlotsInfo(slot, s.k8sInfo); r != "" {
+		remarks = append(remarks, r)
+	}
+
+	if len(slot.Nodes) > 0 {
+		master := s.k8sInfo.GetPodInfo(slot.Nodes[0].Addr)
+		for _, z := range zones {
+			if master.Zone == z.Zone {
+				remarks = append(remarks, "*zone majority*")
+			}
+		}
+	}
+	return remarks
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestUncoveredSlots(t *testing.T) {
+	master1 := redis.ClusterNode{ID: idMaster1, Addr: "10.244.1.5:6379"}
+	master2 := redis.ClusterNode{ID: idMaster2, Addr: "10.244.2.5:6379"}
+	master3 := redis.ClusterNode{ID: idMaster3, Addr: "10.244.3.5:6379"}
+
+	tests := []struct {
+		name  string
+		slots redisutils.ClusterSlots
+		want  []slotRange
+	}{
+		{
+			name:  "all slots covered",
+			slots: testClusterSlots(),
+			want:  []slotRange{},
+		},
+		{
+			name:  "no slots assigned",
+			slots: redisutils.ClusterSlots{},
+			want:  []slotRange{{Start: 0, End: 16383}},
+		},
+		{
+			name: "gaps at the edges and between ranges",
+			slots: redisutils.ClusterSlots{
+				{Start: 1, End: 5460, Nodes: []redis.ClusterNode{master1}},
+				{Start: 5462, End: 10922, Nodes: []redis.ClusterNode{master2}},
+				{Start: 10923, End: 16000, Nodes: []redis.ClusterNode{master3}},
+			},
+			want: []slotRange{{Start: 0, End: 0}, {Start: 5461, End: 5461}, {Start: 16001, End: 16383}},
+		},
+		{
+			name: "single slots",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1}},
+				{Start: 5462, End: 5462, Nodes: []redis.ClusterNode{master2}},
+				{Start: 5464, End: 16383, Nodes: []redis.ClusterNode{master3}},
+			},
+			want: []slotRange{{Start: 5461, End: 5461}, {Start: 5463, End: 5463}},
+		},
+		{
+			name: "out of range slots ignored",
+			slots: redisutils.ClusterSlots{
+				{Start: -10, End: 16383, Nodes: []redis.ClusterNode{master1}},
+				{Start: 16384, End: 16500, Nodes: []redis.ClusterNode{master2}},
+			},
+			want: []slotRange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uncoveredSlots(tt.slots); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var jsonRegexp = regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
+
+type column struct {
+	header string
+	path   *jsonpath.JSONPath
+}
+
+// customColumnsPrinter prints one row per item, like kubectl -o custom-columns
+type customColumnsPrinter struct {
+	columns []column
+}
+
+func newCustomColumnsPrinter(spec string) (*customColumnsPrinter, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+
+	p := &customColumnsPrinter{}
+	for _, part := range strings.Split(spec, ",") {
+		colSpec := strings.SplitN(part, ":", 2)
+		if len(colSpec) != 2 {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", part)
+		}
+		expr, err := relaxedJSONPathExpression(colSpec[1])
+		if err != nil {
+			return nil, err
+		}
+		path := jsonpath.New(colSpec[0]).AllowMissingKeys(true)
+		if err := path.Parse(expr); err != nil {
+			return nil, fmt.Errorf("error parsing jsonpath %s, %v", expr, err)
+		}
+		p.columns = append(p.columns, column{header: colSpec[0], path: path})
+	}
+	return p, nil
+}
+
+// relaxedJSONPathExpression accepts expressions like .pod, pod and {.pod}
+func relaxedJSONPathExpression(expr string) (string, error) {
+	if len(expr) == 0 {
+		return expr, nil
+	}
+	submatches := jsonRegexp.FindStringSubmatch(expr)
+	if submatches == nil {
+		return "", fmt.Errorf("unexpected path string, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'")
+	}
+	if len(submatches) != 3 {
+		return "", fmt.Errorf("unexpected submatch list: %v", submatches)
+	}
+	var fieldSpec string
+	if len(submatches[1]) != 0 {
+		fieldSpec = submatches[1]
+	} else {
+		fieldSpec = submatches[2]
+	}
+	return fmt.Sprintf("{.%s}", fieldSpec), nil
+}
+
+// PrintObj prints the items of a list, or the object itself
+func (p *customColumnsPrinter) PrintObj(obj runtime.Object, out io.Writer) error {
+	content, ok := obj.(runtime.Unstructured)
+	if !ok {
+		return fmt.Errorf("custom-columns can not print %T", obj)
+	}
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	headers := []string{}
+	for _, col := range p.columns {
+		headers = append(headers, col.header)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	object := content.UnstructuredContent()
+	items, isList := object["items"].([]interface{})
+	if !isList {
+		items = []interface{}{object}
+	}
+	for _, item := range items {
+		if err := p.printRow(item, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *customColumnsPrinter) printRow(item interface{}, w io.Writer) error {
+	fields := []string{}
+	for _, col := range p.columns {
+		results, err := col.path.FindResults(item)
+		if err != nil {
+			return err
+		}
+		values := []string{}
+		for _, result := range results {
+			for _, value := range result {
+				buf := &bytes.Buffer{}
+				if err := col.path.PrintResults(buf, []reflect.Value{value}); err != nil {
+					return err
+				}
+				values = append(values, buf.String())
+			}
+		}
+		if len(values) == 0 {
+			values = append(values, "<none>")
+		}
+		fields = append(fields, strings.Join(values, ","))
+	}
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+	return nil
+}
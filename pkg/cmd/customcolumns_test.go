@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRelaxedJSONPathExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: "", want: ""},
+		{expr: "pod", want: "{.pod}"},
+		{expr: ".pod", want: "{.pod}"},
+		{expr: "{.pod}", want: "{.pod}"},
+		{expr: "{pod}", want: "{.pod}"},
+		{expr: ".info.used_memory_human", want: "{.info.used_memory_human}"},
+		{expr: "{.pod", wantErr: true},
+		{expr: "{.a}{.b}", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := relaxedJSONPathExpression(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("relaxedJSONPathExpression(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("relaxedJSONPathExpression(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNewCustomColumnsPrinterErrors(t *testing.T) {
+	for _, spec := range []string{"", "POD", "POD:.pod,ROLE", "POD:{.pod"} {
+		if _, err := newCustomColumnsPrinter(spec); err == nil {
+			t.Errorf("newCustomColumnsPrinter(%q) gave no error", spec)
+		}
+	}
+}
+
+func TestCustomColumnsOutput(t *testing.T) {
+	keys := int64(3328)
+	list := newClusterState().newOutputList("NodeList", []podOutput{
+		{
+			Pod:  "rediscluster-cluster-7tpnv",
+			Role: "master",
+			Keys: &keys,
+			Info: map[string]string{"used_memory_human": "2.51M"},
+			ClusterSlots: []slotRangeOutput{
+				{Start: 0, End: 5460},
+				{Start: 10923, End: 10923},
+			},
+		},
+		{
+			Pod:  "rediscluster-cluster-dqrzl",
+			Role: "replica",
+		},
+	})
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "plain fields",
+			spec: "POD:.pod,ROLE:role",
+			want: "POD                         ROLE\n" +
+				"rediscluster-cluster-7tpnv  master\n" +
+				"rediscluster-cluster-dqrzl  replica\n",
+		},
+		{
+			name: "nested field and missing value",
+			spec: "POD:{.pod},MEM:.info.used_memory_human,KEYS:.keys",
+			want: "POD                         MEM     KEYS\n" +
+				"rediscluster-cluster-7tpnv  2.51M   3328\n" +
+				"rediscluster-cluster-dqrzl  <none>  <none>\n",
+		},
+		{
+			name: "multiple values joined",
+			spec: "POD:.pod,START:.clusterSlots[*].start",
+			want: "POD                         START\n" +
+				"rediscluster-cluster-7tpnv  0,10923\n" +
+				"rediscluster-cluster-dqrzl  <none>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := printOutput(list, outputCustomColumns+"="+tt.spec, out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
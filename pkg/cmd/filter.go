@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/pflag"
+)
+
+// Sort orders, numeric orders are sorted with the largest value first
+const (
+	sortByKeys   = "keys"
+	sortBySlots  = "slots"
+	sortByUptime = "uptime"
+	sortByMemory = "memory"
+	sortByHost   = "host"
+	sortByRole   = "role"
+)
+
+var sortByValues = []string{sortByKeys, sortBySlots, sortByUptime, sortByMemory, sortByHost, sortByRole}
+
+const (
+	roleMaster  = "master"
+	roleReplica = "replica"
+)
+
+// podFilter selects and orders the pods to show
+type podFilter struct {
+	sortBy string
+	role   string
+	host   string
+	pod    string
+}
+
+func (f *podFilter) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.sortBy, "sort-by", "", "Sort by one of: keys|slots|uptime|memory|host|role")
+	flags.StringVar(&f.role, "role", "", "Only show instances with role: master|replica")
+	flags.StringVar(&f.host, "host", "", "Only show pods on given K8s node")
+	flags.StringVar(&f.pod, "pod", "", "Only show pods with a name matching given glob pattern")
+}
+
+func (f *podFilter) validate() error {
+	if f.sortBy != "" && !contains(sortByValues, f.sortBy) {
+		return fmt.Errorf("unsupported sort order: %s, allowed values are: %v", f.sortBy, sortByValues)
+	}
+	if f.role != "" && f.role != roleMaster && f.role != roleReplica {
+		return fmt.Errorf("unsupported role: %s, allowed values are: %s|%s", f.role, roleMaster, roleReplica)
+	}
+	if _, err := path.Match(f.pod, ""); err != nil {
+		return fmt.Errorf("invalid pod pattern %s: %v", f.pod, err)
+	}
+	return nil
+}
+
+// matchPod checks the K8s related filters
+func (f *podFilter) matchPod(p k8s.PodInfo) bool {
+	if f.host != "" && p.Host != f.host {
+		return false
+	}
+	if f.pod != "" {
+		if ok, _ := path.Match(f.pod, p.Name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRole checks the role filter
+func (f *podFilter) matchRole(role string) bool {
+	return f.role == "" || f.role == role
+}
+
+// filterPods returns the pods that matches all filters, in the selected order
+func (f *podFilter) filterPods(s *clusterState, pods []k8s.PodInfo) []k8s.PodInfo {
+	result := []k8s.PodInfo{}
+	for _, p := range pods {
+		if f.matchPod(p) && f.matchRole(s.podRole(p.Name)) {
+			result = append(result, p)
+		}
+	}
+
+	if f.sortBy != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			return f.less(s, result[i], result[j])
+		})
+	}
+	return result
+}
+
+// less compares two pods using the selected sort order
+func (f *podFilter) less(s *clusterState, a k8s.PodInfo, b k8s.PodInfo) bool {
+	switch f.sortBy {
+	case sortByKeys:
+		return s.podInfoValue(a.Name, "keys") > s.podInfoValue(b.Name, "keys")
+	case sortByMemory:
+		return s.podInfoValue(a.Name, "used_memory") > s.podInfoValue(b.Name, "used_memory")
+	case sortByUptime:
+		return s.podInfoValue(a.Name, "uptime_in_seconds") > s.podInfoValue(b.Name, "uptime_in_seconds")
+	case sortBySlots:
+		slotsA, _ := slotsCount(a.IP, s.redisSlots[a.Name])
+		slotsB, _ := slotsCount(b.IP, s.redisSlots[b.Name])
+		return slotsA > slotsB
+	case sortByHost:
+		return a.Host < b.Host
+	case sortByRole:
+		return s.podRole(a.Name) < s.podRole(b.Name)
+	}
+	return false
+}
+
+// filterSlots returns the slot ranges with the instances that matches all filters, in the selected order
+func (f *podFilter) filterSlots(s *clusterState, slots redisutils.ClusterSlots) redisutils.ClusterSlots {
+	result := redisutils.ClusterSlots{}
+	for _, slot := range slots {
+		// Ranges without nodes, like uncovered slots, are only shown when not filtering
+		matching := len(slot.Nodes) == 0 && f.role == "" && f.host == "" && f.pod == ""
+		for i, node := range slot.Nodes {
+			if f.matchSlotNode(s, i, node.Addr) {
+				matching = true
+				break
+			}
+		}
+		if matching {
+			result = append(result, slot)
+		}
+	}
+
+	if f.sortBy != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			if f.sortBy == sortBySlots {
+				return result[i].End-result[i].Start > result[j].End-result[j].Start
+			}
+			if len(result[i].Nodes) == 0 || len(result[j].Nodes) == 0 {
+				return len(result[j].Nodes) == 0 && len(result[i].Nodes) > 0
+			}
+			return f.less(s, s.k8sInfo.GetPodInfo(result[i].Nodes[0].Addr),
+				s.k8sInfo.GetPodInfo(result[j].Nodes[0].Addr))
+		})
+	}
+	return result
+}
+
+// matchSlotNode checks if the instance at given position in a slot range matches all filters
+func (f *podFilter) matchSlotNode(s *clusterState, index int, addr string) bool {
+	role := roleReplica
+	if index == 0 {
+		role = roleMaster
+	}
+	return f.matchRole(role) && f.matchPod(s.k8sInfo.GetPodInfo(addr))
+}
+
+// podRole returns the role reported by the pods own Redis instance
+func (s *clusterState) podRole(podName string) string {
+	nodes := s.redisNodes[podName]
+	if self, found := nodes.GetSelf(); found {
+		return self.Role()
+	}
+	return ""
+}
+
+// podInfoValue returns a numeric Redis INFO field, or -1 when not available
+func (s *clusterState) podInfoValue(podName string, field string) int64 {
+	value, err := strconv.ParseInt(s.redisInfo[podName][field], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Link states between an observing pod and a target pod
+const (
+	linkSelf         = "-"
+	linkUnknown      = "?"
+	linkOK           = "ok"
+	linkPFail        = "fail?"
+	linkFail         = "fail"
+	linkHandshake    = "handshake"
+	linkNoAddr       = "noaddr"
+	linkDisconnected = "disconnected"
+	linkMissing      = "missing"
+)
+
+type healthMatrixCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+
+	*clusterState
+}
+
+// A row in the health matrix, how a pod sees all other pods
+type healthMatrixRow struct {
+	Pod     string       `json:"pod"`
+	Targets []linkHealth `json:"targets"`
+}
+
+// How an observing pod sees a target pod, from the observers CLUSTER NODES view
+type linkHealth struct {
+	Pod       string   `json:"pod"`
+	State     string   `json:"state"`
+	Flags     []string `json:"flags,omitempty"`
+	LinkState string   `json:"linkState,omitempty"`
+
+	// Milliseconds since the last pong was received, and since an unanswered ping was sent
+	PongAge int64 `json:"pongAge,omitempty"`
+	PingAge int64 `json:"pingAge,omitempty"`
+}
+
+// NewHealthCmd initialize and creates a Cobra command
+func NewHealthCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "health",
+		Short: "Show health views of a Redis Cluster",
+	}
+	cmd.AddCommand(newHealthMatrixCmd(streams))
+	return cmd
+}
+
+func newHealthMatrixCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &healthMatrixCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "matrix [service-name] [flags]",
+		Short: "Show how each pod sees the health of all other pods",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputWideUsage)
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *healthMatrixCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *healthMatrixCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, append(outputFormats, outputWide)...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *healthMatrixCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return printOutput(c.newOutputList("HealthMatrix", c.healthMatrix()), c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *healthMatrixCmd) outputResult(out io.Writer) {
+	if len(c.k8sInfo.Pods) == 0 {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any pod information to show..")
+		return
+	}
+
+	podList := c.podList()
+	matrix := c.healthMatrix()
+	names := shortPodNames(podList)
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	// Observers as rows and targets as columns, using the short pod names
+	fmt.Fprintf(w, "OBSERVER\t%s\t\n", strings.Join(names, "\t"))
+	for _, row := range matrix {
+		cells := []string{}
+		for _, t := range row.Targets {
+			cells = append(cells, c.formatLinkHealth(t))
+		}
+		fmt.Fprintf(w, "%s\t%s\t\n", row.Pod, strings.Join(cells, "\t"))
+	}
+
+	// Print the conclusions
+	if findings := healthFindings(matrix); len(findings) > 0 {
+		fmt.Fprintf(w, "\n")
+		for _, text := range findings {
+			fmt.Fprintf(w, "%s\n", text)
+		}
+	}
+
+	// Print errors
+	c.outputErrors(w)
+}
+
+// formatLinkHealth returns the text of a matrix cell, wide output includes the ping and pong ages
+func (c *healthMatrixCmd) formatLinkHealth(l linkHealth) string {
+	if c.output != outputWide || l.State == linkSelf || l.State == linkUnknown || l.State == linkMissing {
+		return l.State
+	}
+	text := l.State
+	if l.PongAge > 0 {
+		text += fmt.Sprintf(" pong=%s", formatAge(l.PongAge))
+	}
+	if l.PingAge > 0 {
+		text += fmt.Sprintf(" ping=%s", formatAge(l.PingAge))
+	}
+	return text
+}
+
+// healthMatrix returns how each pod sees all pods, in the default pod order
+func (s *clusterState) healthMatrix() []healthMatrixRow {
+	podList := s.podList()
+	matrix := []healthMatrixRow{}
+	for _, observer := range podList {
+		row := healthMatrixRow{Pod: observer.Name, Targets: []linkHealth{}}
+		for _, target := range podList {
+			row.Targets = append(row.Targets, s.linkHealth(observer, target))
+		}
+		matrix = append(matrix, row)
+	}
+	return matrix
+}
+
+// linkHealth returns how the observer sees the target
+func (s *clusterState) linkHealth(observer k8s.PodInfo, target k8s.PodInfo) linkHealth {
+	result := linkHealth{Pod: target.Name}
+	nodes, ok := s.redisNodes[observer.Name]
+	switch {
+	case observer.Name == target.Name:
+		result.State = linkSelf
+		return result
+	case !ok:
+		result.State = linkUnknown
+		return result
+	}
+
+	node, found := nodes.GetNodeByIP(target.IP)
+	if !found {
+		result.State = linkMissing
+		return result
+	}
+	result.Flags = node.Flags
+	result.LinkState = node.LinkState
+
+	now := s.serverTime(observer.Name)
+	if node.PongRecv > 0 {
+		result.PongAge = now - node.PongRecv
+	}
+	if node.PingSent > 0 {
+		result.PingAge = now - node.PingSent
+	}
+
+	switch {
+	case node.HasFlag(linkFail):
+		result.State = linkFail
+	case node.HasFlag(linkPFail):
+		result.State = linkPFail
+	case node.HasFlag(linkHandshake):
+		result.State = linkHandshake
+	case node.HasFlag(linkNoAddr):
+		result.State = linkNoAddr
+	case node.LinkState == linkDisconnected:
+		result.State = linkDisconnected
+	default:
+		result.State = linkOK
+	}
+	return result
+}
+
+// serverTime returns the time in milliseconds according to a pods Redis instance,
+// or the local time when not available
+func (s *clusterState) serverTime(podName string) int64 {
+	if usec, err := strconv.ParseInt(s.redisInfo[podName]["server_time_usec"], 10, 64); err == nil {
+		return usec / 1000
+	}
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// isFailing returns true when the observer considers the target to be down or unreachable
+func (l *linkHealth) isFailing() bool {
+	switch l.State {
+	case linkFail, linkPFail, linkNoAddr, linkDisconnected:
+		return true
+	}
+	return false
+}
+
+// healthFindings summarizes the matrix, like if the cluster agrees that a pod is down,
+// or if a pod sees all others as down and is likely isolated itself
+func healthFindings(matrix []healthMatrixRow) []string {
+	findings := []string{}
+
+	// Per target, the observers that consider it failing
+	for col := range matrix {
+		target := matrix[col].Pod
+		observers := 0
+		failing := []string{}
+		for _, row := range matrix {
+			l := row.Targets[col]
+			if l.State == linkSelf || l.State == linkUnknown {
+				continue
+			}
+			observers++
+			if l.isFailing() {
+				failing = append(failing, row.Pod)
+			}
+		}
+		if len(failing) == 0 {
+			continue
+		}
+		if len(failing) == len(matrix)-1 {
+			findings = append(findings, fmt.Sprintf("%s is seen as failing by all other pods", target))
+		} else {
+			findings = append(findings, fmt.Sprintf("%s is seen as failing by %d of %d pods: %s",
+				target, len(failing), observers, strings.Join(failing, ", ")))
+		}
+	}
+
+	// Observers that see all other pods as failing
+	for _, row := range matrix {
+		targets := 0
+		failing := 0
+		for _, l := range row.Targets {
+			if l.State == linkSelf || l.State == linkUnknown {
+				continue
+			}
+			targets++
+			if l.isFailing() {
+				failing++
+			}
+		}
+		if targets > 1 && failing == targets {
+			findings = append(findings, fmt.Sprintf("%s sees all other pods as failing, it is likely isolated", row.Pod))
+		}
+	}
+	return findings
+}
+
+// shortPodNames returns the pod names without their common prefix, like the generated suffix
+func shortPodNames(pods []k8s.PodInfo) []string {
+	prefix := ""
+	if len(pods) > 1 {
+		prefix = pods[0].Name
+		for _, p := range pods[1:] {
+			for !strings.HasPrefix(p.Name, prefix) {
+				prefix = prefix[:len(prefix)-1]
+			}
+		}
+		// Keep the names readable by cutting at a separator
+		prefix = prefix[:strings.LastIndex(prefix, "-")+1]
+	}
+
+	names := []string{}
+	for _, p := range pods {
+		names = append(names, strings.TrimPrefix(p.Name, prefix))
+	}
+	return names
+}
+
+// formatAge formats milliseconds as a rounded duration
+func formatAge(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	if d >= time.Second {
+		d = d.Round(100 * time.Millisecond)
+	}
+	return d.String()
+}
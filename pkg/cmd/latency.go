@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Defaults of the PING round-trip probe
+const (
+	defaultPingSamples  = 20
+	defaultPingInterval = 10 * time.Millisecond
+)
+
+// Output document of the latency command, with the PING round-trips per K8s host
+type latencyList struct {
+	outputList
+	HostLatencies []hostLatency `json:"hostLatencies,omitempty"`
+}
+
+type latencyCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	samples     int
+	interval    time.Duration
+	top         int
+
+	*clusterState
+}
+
+// Latency of a pod, measured by PING round-trips and reported by Redis.
+// All durations are in microseconds, except for latency events which Redis reports in milliseconds.
+type podLatency struct {
+	Pod         string           `json:"pod"`
+	Role        string           `json:"role,omitempty"`
+	Host        string           `json:"host"`
+	PingSamples []int64          `json:"pingSamplesUs"`
+	PingMin     int64            `json:"pingMinUs"`
+	PingAvg     int64            `json:"pingAvgUs"`
+	PingP99     int64            `json:"pingP99Us"`
+	PingMax     int64            `json:"pingMaxUs"`
+	Events      []latencyEvent   `json:"events"`
+	Commands    []commandLatency `json:"commands,omitempty"`
+}
+
+// A latency event from LATENCY LATEST
+type latencyEvent struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	LatestMs int64     `json:"latestMs"`
+	MaxMs    int64     `json:"maxMs"`
+}
+
+// Latency of a command from LATENCY HISTOGRAM, percentiles are bucket upper bounds
+type commandLatency struct {
+	Pod     string `json:"pod"`
+	Command string `json:"command"`
+	Calls   int64  `json:"calls"`
+	P50     int64  `json:"p50Us"`
+	P99     int64  `json:"p99Us"`
+}
+
+// PING round-trips of all pods on a K8s host, in microseconds
+type hostLatency struct {
+	Host    string `json:"host"`
+	Pods    int    `json:"pods"`
+	PingAvg int64  `json:"pingAvgUs"`
+	PingP99 int64  `json:"pingP99Us"`
+}
+
+// NewLatencyCmd initialize and creates a Cobra command
+func NewLatencyCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &latencyCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "latency [service-name] [flags]",
+		Short: "Show latency events and PING round-trips of a Redis Cluster per pod and K8s host",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().IntVar(&c.samples, "samples", defaultPingSamples, "Number of PING round-trips to measure per pod")
+	cmd.Flags().DurationVar(&c.interval, "interval", defaultPingInterval, "Time between the PINGs")
+	cmd.Flags().IntVar(&c.top, "top", 10, "Number of commands with the highest latency to show")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *latencyCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *latencyCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.samples <= 0 {
+		return fmt.Errorf("samples must be a positive number, got %d", c.samples)
+	}
+	if c.interval < 0 {
+		return fmt.Errorf("interval can not be negative, got %s", c.interval)
+	}
+	if c.top <= 0 {
+		return fmt.Errorf("top must be a positive number, got %d", c.top)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *latencyCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+	// Reuse the connections of the collect when running the commands on the pods
+	query.keepConnections = true
+	defer query.close()
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	latencies := c.measureLatencies(query)
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		list := latencyList{outputList: *c.newOutputList("LatencyList", latencies), HostLatencies: hostLatencies(latencies)}
+		return printOutput(list, c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out, latencies)
+
+	return nil
+}
+
+func (c *latencyCmd) outputResult(out io.Writer, latencies []podLatency) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\t\t\tPING\tPING\tPING\tPING\t")
+	fmt.Fprintln(w, "PODNAME\tROLE\tHOST\tMIN\tAVG\tP99\tMAX\tEVENTS")
+	for _, l := range latencies {
+		events := []string{}
+		for _, e := range l.Events {
+			events = append(events, fmt.Sprintf("%s (max %dms)", e.Event, e.MaxMs))
+		}
+		if len(l.PingSamples) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t\t\t\t\t%s\n", l.Pod, l.Role, l.Host, strings.Join(events, ", "))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", l.Pod, l.Role, l.Host,
+			formatMicros(l.PingMin), formatMicros(l.PingAvg), formatMicros(l.PingP99), formatMicros(l.PingMax),
+			strings.Join(events, ", "))
+	}
+
+	if hosts := hostLatencies(latencies); len(hosts) > 0 {
+		fmt.Fprintf(w, "\nPING round-trip per host:\n")
+		fmt.Fprintln(w, "HOST\tPODS\tAVG\tP99")
+		for _, h := range hosts {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", h.Host, h.Pods, formatMicros(h.PingAvg), formatMicros(h.PingP99))
+		}
+	}
+
+	addHeader := true
+	for _, l := range latencies {
+		for _, e := range l.Events {
+			if addHeader {
+				fmt.Fprintf(w, "\nLatency events:\n")
+				fmt.Fprintln(w, "PODNAME\tHOST\tEVENT\tTIME\tLATEST\tMAX")
+				addHeader = false
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%dms\t%dms\n",
+				l.Pod, l.Host, e.Event, e.Time.Format("2006-01-02 15:04:05"), e.LatestMs, e.MaxMs)
+		}
+	}
+
+	if commands := topCommandLatencies(latencies, c.top); len(commands) > 0 {
+		fmt.Fprintf(w, "\nCommand latency:\n")
+		fmt.Fprintln(w, "PODNAME\tCOMMAND\tCALLS\tP50\tP99")
+		for _, cl := range commands {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+				cl.Pod, cl.Command, cl.Calls, formatMicros(cl.P50), formatMicros(cl.P99))
+		}
+	}
+
+	c.outputErrors(w)
+}
+
+// measureLatencies fetches the latency events and histograms, and measures the PING
+// round-trips over a held connection, of all pods in parallel
+func (c *latencyCmd) measureLatencies(query *clusterQuery) []podLatency {
+	pods := []k8s.PodInfo{}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; ok {
+			pods = append(pods, p)
+		}
+	}
+	results := query.runOnPods(pods, func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error) {
+		l := podLatency{Pod: pod.Name, Role: c.podRole(pod.Name), Host: pod.Host, Events: []latencyEvent{}}
+
+		samples, err := conn.PingSamples(c.samples, c.interval)
+		l.setPingSamples(samples)
+		if err != nil {
+			return l, err
+		}
+
+		events, err := conn.LatencyLatest()
+		if err != nil {
+			return l, err
+		}
+		for _, e := range events {
+			l.Events = append(l.Events, latencyEvent{
+				Event:    e.Event,
+				Time:     e.Time,
+				LatestMs: e.Latest.Milliseconds(),
+				MaxMs:    e.Max.Milliseconds(),
+			})
+		}
+
+		histograms, err := conn.LatencyHistograms()
+		for _, h := range histograms {
+			if h.Calls == 0 {
+				continue
+			}
+			l.Commands = append(l.Commands, commandLatency{
+				Pod:     pod.Name,
+				Command: h.Command,
+				Calls:   h.Calls,
+				P50:     h.Percentile(50).Microseconds(),
+				P99:     h.Percentile(99).Microseconds(),
+			})
+		}
+		return l, err
+	})
+
+	result := []podLatency{}
+	for _, r := range results {
+		if r.err != nil {
+			c.errors[r.pod.Name] = append(c.errors[r.pod.Name], fmt.Sprintf("latency failed: %v", r.err))
+		}
+		if l, ok := r.value.(podLatency); ok {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// setPingSamples sets the samples and their statistics
+func (l *podLatency) setPingSamples(samples []time.Duration) {
+	l.PingSamples = []int64{}
+	for _, s := range samples {
+		l.PingSamples = append(l.PingSamples, s.Microseconds())
+	}
+	if len(l.PingSamples) == 0 {
+		return
+	}
+	sorted := sortedCopy(l.PingSamples)
+	l.PingMin = sorted[0]
+	l.PingMax = sorted[len(sorted)-1]
+	l.PingAvg = average(sorted)
+	l.PingP99 = percentile(sorted, 99)
+}
+
+// hostLatencies returns the PING round-trips of all pods per K8s host, ordered by host
+func hostLatencies(latencies []podLatency) []hostLatency {
+	samples := map[string][]int64{}
+	pods := map[string]int{}
+	hosts := []string{}
+	for _, l := range latencies {
+		if len(l.PingSamples) == 0 {
+			continue
+		}
+		if _, found := samples[l.Host]; !found {
+			hosts = append(hosts, l.Host)
+		}
+		samples[l.Host] = append(samples[l.Host], l.PingSamples...)
+		pods[l.Host]++
+	}
+	sort.Strings(hosts)
+
+	result := []hostLatency{}
+	for _, host := range hosts {
+		sorted := sortedCopy(samples[host])
+		result = append(result, hostLatency{
+			Host:    host,
+			Pods:    pods[host],
+			PingAvg: average(sorted),
+			PingP99: percentile(sorted, 99),
+		})
+	}
+	return result
+}
+
+// topCommandLatencies returns the commands with the highest 99th percentile latency of all pods
+func topCommandLatencies(latencies []podLatency, n int) []commandLatency {
+	result := []commandLatency{}
+	for _, l := range latencies {
+		result = append(result, l.Commands...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].P99 != result[j].P99 {
+			return result[i].P99 > result[j].P99
+		}
+		return result[i].Calls > result[j].Calls
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+func sortedCopy(values []int64) []int64 {
+	sorted := append([]int64{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func average(values []int64) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / int64(len(values))
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int64, p int) int64 {
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatMicros formats a duration given in microseconds
+func formatMicros(us int64) string {
+	return (time.Duration(us) * time.Microsecond).String()
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	// Round-trip times of PINGs in microseconds
+	samples := []int64{412, 388, 2051, 395, 401, 376, 420, 398, 1208, 390}
+
+	tests := []struct {
+		values []int64
+		p      int
+		want   int64
+	}{
+		{values: samples, p: 0, want: 376},
+		{values: samples, p: 10, want: 376},
+		{values: samples, p: 50, want: 398},
+		{values: samples, p: 51, want: 401},
+		{values: samples, p: 90, want: 1208},
+		{values: samples, p: 99, want: 2051},
+		{values: samples, p: 100, want: 2051},
+		{values: []int64{250}, p: 99, want: 250},
+	}
+	for _, tt := range tests {
+		if got := percentile(sortedCopy(tt.values), tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %d, want %d", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestAverage(t *testing.T) {
+	tests := []struct {
+		values []int64
+		want   int64
+	}{
+		{values: []int64{412, 388, 2051, 395}, want: 811},
+		{values: []int64{250}, want: 250},
+	}
+	for _, tt := range tests {
+		if got := average(tt.values); got != tt.want {
+			t.Errorf("average(%v) = %d, want %d", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Remark for pods that are not known by the other cluster members
+const remarkNotMember = "*not a member*"
+
+// Result from reconciling the K8s pods with the members in the CLUSTER NODES view of all pods
+type membershipAnalysis struct {
+	// Cluster members without a backing pod, like a stale node ID with the address of a restarted pod
+	Ghosts []clusterMember `json:"ghosts,omitempty"`
+	// Cluster members in handshake, or without a known address
+	Stuck []clusterMember `json:"stuck,omitempty"`
+	// Pods that are not known by any other pod
+	NonMembers []string `json:"nonMembers,omitempty"`
+}
+
+// A node found in the CLUSTER NODES view of one or more pods
+type clusterMember struct {
+	ID     string   `json:"id"`
+	Addr   string   `json:"addr"`
+	Flags  []string `json:"flags"`
+	SeenBy []string `json:"seenBy"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// analyzeMembership reconciles the K8s pods with the union of all CLUSTER NODES views
+func (s *clusterState) analyzeMembership() membershipAnalysis {
+	result := membershipAnalysis{
+		Ghosts:     []clusterMember{},
+		Stuck:      []clusterMember{},
+		NonMembers: []string{},
+	}
+
+	// Node ID of each pod, as reported by itself
+	podIDs := map[string]string{}
+	idPods := map[string]string{}
+	for _, p := range s.podList() {
+		nodes := s.redisNodes[p.Name]
+		if self, found := nodes.GetSelf(); found {
+			podIDs[p.Name] = self.ID
+			idPods[self.ID] = p.Name
+		}
+	}
+
+	// All members seen by any pod
+	members := map[string]*clusterMember{}
+	ids := []string{}
+	for _, p := range s.podList() {
+		nodes := s.redisNodes[p.Name]
+		for _, n := range nodes.List() {
+			m, found := members[n.ID]
+			if !found {
+				m = &clusterMember{ID: n.ID, Addr: nodeAddr(n.Addr), Flags: n.Flags}
+				members[n.ID] = m
+				ids = append(ids, n.ID)
+			}
+			m.SeenBy = append(m.SeenBy, p.Name)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		m := members[id]
+		if _, known := idPods[id]; known {
+			continue
+		}
+		if contains(m.Flags, "handshake") || contains(m.Flags, "noaddr") {
+			result.Stuck = append(result.Stuck, *m)
+			continue
+		}
+
+		pod := s.k8sInfo.GetPodInfo(m.Addr)
+		if pod.Name == "" {
+			m.Reason = "no pod with this address"
+			result.Ghosts = append(result.Ghosts, *m)
+		} else if podID, found := podIDs[pod.Name]; found && podID != id {
+			m.Reason = fmt.Sprintf("the address is used by %s with node ID %s", pod.Name, podID)
+			result.Ghosts = append(result.Ghosts, *m)
+		}
+	}
+
+	// Pods that no other pod knows about, by node ID or by address when the pods ID is unknown
+	for _, p := range s.podList() {
+		others := 0
+		knownBy := 0
+		for _, o := range s.podList() {
+			nodes, ok := s.redisNodes[o.Name]
+			if !ok || o.Name == p.Name {
+				continue
+			}
+			others++
+			if id, found := podIDs[p.Name]; found {
+				if _, known := nodes.GetNode(id); known {
+					knownBy++
+				}
+			} else if _, known := nodes.GetNodeByIP(p.IP); known {
+				knownBy++
+			}
+		}
+		if others > 0 && knownBy == 0 {
+			result.NonMembers = append(result.NonMembers, p.Name)
+		}
+	}
+	return result
+}
+
+// addMembershipRemarks adds a remark to the pods that are not cluster members
+func (s *clusterState) addMembershipRemarks() {
+	for _, pod := range s.analyzeMembership().NonMembers {
+		s.remarks[pod] = append(s.remarks[pod], remarkNotMember)
+	}
+}
+
+// findings returns a description of each finding
+func (a *membershipAnalysis) findings() []string {
+	result := []string{}
+	for _, m := range a.Ghosts {
+		result = append(result, fmt.Sprintf("member without pod: %s %s [%s] seen by %s: %s",
+			m.ID, m.Addr, strings.Join(m.Flags, ","), strings.Join(m.SeenBy, ", "), m.Reason))
+	}
+	for _, m := range a.Stuck {
+		result = append(result, fmt.Sprintf("member stuck: %s %s [%s] seen by %s",
+			m.ID, m.Addr, strings.Join(m.Flags, ","), strings.Join(m.SeenBy, ", ")))
+	}
+	for _, pod := range a.NonMembers {
+		result = append(result, fmt.Sprintf("pod not a member: %s is not known by any other pod", pod))
+	}
+	return result
+}
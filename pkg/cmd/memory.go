@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Remarks added by the memory analysis
+const (
+	remarkMaxmemoryUnset      = "*maxmemory unset*"
+	remarkMaxmemoryAboveLimit = "*maxmemory above limit*"
+	remarkNearMaxmemory       = "*near maxmemory*"
+	remarkNearLimit           = "*near limit*"
+)
+
+// Default usage, in percent of maxmemory or the container limit, above which a pod is flagged
+const defaultMemoryThreshold = 90
+
+type memoryCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	threshold   int
+
+	*clusterState
+}
+
+// Memory usage of a Redis instance and the memory resources of its container, in bytes
+type memoryInfo struct {
+	Pod                string   `json:"pod"`
+	Role               string   `json:"role,omitempty"`
+	UsedMemory         int64    `json:"usedMemory"`
+	UsedMemoryRSS      int64    `json:"usedMemoryRss"`
+	UsedMemoryPeak     int64    `json:"usedMemoryPeak"`
+	FragmentationRatio float64  `json:"fragmentationRatio"`
+	MaxMemory          int64    `json:"maxMemory"`
+	MaxMemoryPolicy    string   `json:"maxMemoryPolicy,omitempty"`
+	MemoryRequest      int64    `json:"memoryRequest,omitempty"`
+	MemoryLimit        int64    `json:"memoryLimit,omitempty"`
+	Remarks            []string `json:"remarks,omitempty"`
+}
+
+// NewMemoryCmd initialize and creates a Cobra command
+func NewMemoryCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &memoryCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "memory [service-name] [flags]",
+		Short: "Show the memory usage of a Redis Cluster compared with the container resources",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().IntVar(&c.threshold, "threshold", defaultMemoryThreshold, "Usage in percent of maxmemory, or of the container limit, above which a pod is flagged")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *memoryCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *memoryCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.threshold <= 0 || c.threshold > 100 {
+		return fmt.Errorf("threshold must be a percentage between 1 and 100, got %d", c.threshold)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *memoryCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return printOutput(c.newOutputList("MemoryList", c.memoryInfos(c.threshold)), c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *memoryCmd) outputResult(out io.Writer) {
+	if len(c.redisInfo) == 0 {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any Redis INFO data to show..")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "PODNAME\tROLE\tUSED\tRSS\tPEAK\tFRAG\tMAXMEMORY\tPOLICY\tREQUEST\tLIMIT\tREMARKS")
+	for _, m := range c.memoryInfos(c.threshold) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.2f\t%s\t%s\t%s\t%s\t%s\n",
+			m.Pod, m.Role, formatBytes(m.UsedMemory), formatBytes(m.UsedMemoryRSS), formatBytes(m.UsedMemoryPeak),
+			m.FragmentationRatio, formatBytes(m.MaxMemory), m.MaxMemoryPolicy,
+			formatResource(m.MemoryRequest), formatResource(m.MemoryLimit), strings.Join(m.Remarks, ", "))
+	}
+
+	c.outputErrors(w)
+}
+
+// memoryInfos returns the memory usage of all queried pods. A pod is flagged when maxmemory
+// is unset or above the container limit, since the container is then OOM killed before
+// Redis evicts keys, or when the usage is above the threshold in percent.
+func (s *clusterState) memoryInfos(threshold int) []memoryInfo {
+	result := []memoryInfo{}
+	for _, p := range s.podList() {
+		info, ok := s.redisInfo[p.Name]
+		if !ok {
+			continue
+		}
+		m := memoryInfo{
+			Pod:             p.Name,
+			Role:            s.podRole(p.Name),
+			UsedMemory:      s.podInfoValue(p.Name, "used_memory"),
+			UsedMemoryRSS:   s.podInfoValue(p.Name, "used_memory_rss"),
+			UsedMemoryPeak:  s.podInfoValue(p.Name, "used_memory_peak"),
+			MaxMemory:       s.podInfoValue(p.Name, "maxmemory"),
+			MaxMemoryPolicy: info["maxmemory_policy"],
+			MemoryRequest:   p.MemoryRequest,
+			MemoryLimit:     p.MemoryLimit,
+		}
+		m.FragmentationRatio, _ = strconv.ParseFloat(info["mem_fragmentation_ratio"], 64)
+
+		if m.MaxMemory == 0 {
+			m.Remarks = append(m.Remarks, remarkMaxmemoryUnset)
+		}
+		if m.MemoryLimit > 0 && m.MaxMemory > m.MemoryLimit {
+			m.Remarks = append(m.Remarks, remarkMaxmemoryAboveLimit)
+		}
+		if m.MaxMemory > 0 && m.UsedMemory*100 >= m.MaxMemory*int64(threshold) {
+			m.Remarks = append(m.Remarks, remarkNearMaxmemory)
+		}
+		if m.MemoryLimit > 0 && m.UsedMemoryRSS*100 >= m.MemoryLimit*int64(threshold) {
+			m.Remarks = append(m.Remarks, remarkNearLimit)
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// formatBytes formats a number of bytes using binary units, like the K8s quantities.
+// A negative value, like a missing Redis INFO field, gives an empty string.
+func formatBytes(bytes int64) string {
+	if bytes < 0 {
+		return ""
+	}
+	value := float64(bytes)
+	for _, unit := range []string{"", "Ki", "Mi", "Gi"} {
+		if value < 1024 {
+			if unit == "" {
+				return fmt.Sprintf("%d", bytes)
+			}
+			return fmt.Sprintf("%.1f%s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.1fTi", value)
+}
+
+// formatResource formats a container resource, which is not set when zero
+func formatResource(bytes int64) string {
+	if bytes == 0 {
+		return ""
+	}
+	return formatBytes(bytes)
+}
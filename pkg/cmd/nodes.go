@@ -2,45 +2,43 @@ package cmd
 
 import (
 	"fmt"
-	"sort"
+	"io"
 	"strconv"
 	"text/tabwriter"
 	"time"
 
-	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
-	"github.com/bjosv/kubectl-rediscluster/pkg/portforwarder"
 	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
+// Output document of the nodes command, with the findings when comparing the CLUSTER NODES view of all pods
+type nodeList struct {
+	outputList
+	NodesAnalysis clusterNodesAnalysis `json:"nodesAnalysis"`
+	Membership    membershipAnalysis   `json:"membership"`
+	OpenSlots     []openSlot           `json:"openSlots,omitempty"`
+}
+
 type nodesCmd struct {
 	configFlags *genericclioptions.ConfigFlags
 	streams     *genericclioptions.IOStreams
 	args        []string
 	verbose     bool
+	output      string
+	filter      podFilter
+	watch       watchFlags
 
-	k8sInfo    *k8s.ClusterInfo
-	redisInfo  map[string]redisutils.RedisInfo
-	redisSlots map[string]redisutils.ClusterSlots
-	redisNodes map[string]redisutils.ClusterNodes
-	remarks    map[string][]string
-	errors     map[string][]string
+	*clusterState
 }
 
 // NewNodesCmd initialize and creates a Cobra command
 func NewNodesCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	c := &nodesCmd{
-		configFlags: genericclioptions.NewConfigFlags(true),
-		streams:     &streams,
-		k8sInfo:     k8s.NewClusterInfo(),
-		redisInfo:   make(map[string]redisutils.RedisInfo),
-		redisNodes:  make(map[string]redisutils.ClusterNodes),
-		redisSlots:  make(map[string]redisutils.ClusterSlots),
-		remarks:     make(map[string][]string),
-		errors:      make(map[string][]string),
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
 	}
 
 	cmd := &cobra.Command{
@@ -65,6 +63,9 @@ func NewNodesCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	c.configFlags.AddFlags(cmd.Flags())
 
 	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputWideUsage)
+	c.filter.addFlags(cmd.Flags())
+	c.watch.addFlags(cmd.Flags())
 	return cmd
 }
 
@@ -80,117 +81,86 @@ func (c *nodesCmd) Validate() error {
 	if len(c.args) > 1 {
 		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
 	}
+	if err := validateOutput(c.output, append(outputFormats, outputWide)...); err != nil {
+		return err
+	}
+	if err := c.filter.validate(); err != nil {
+		return err
+	}
+	if err := c.watch.validate(c.output); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 // Run the command
 func (c *nodesCmd) Run() error {
-	namespace, err := k8s.CurrentNamespace(c.configFlags)
-	if err != nil {
-		return err
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
 	}
-
-	restConfig, err := c.configFlags.ToRESTConfig()
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
 	if err != nil {
 		return err
 	}
-
-	serviceName := ""
-	if len(c.args) > 0 {
-		serviceName = c.args[0]
-	} else {
-		serviceName, err = k8s.FindServiceUsingPort(restConfig, namespace, redisutils.RedisPort)
-		if err != nil {
-			return fmt.Errorf("%s\n\nPlease provide a service name", err)
-		}
-		fmt.Fprintf(c.streams.Out, "Using service name: %s\n", serviceName)
+	// The zone is only shown in the wide and machine-readable output
+	query.withZones = c.output == outputWide || isMachineOutput(c.output)
+
+	if c.watch.enabled {
+		return runWatch(query, c.watch.interval, c.streams.Out, func(state *clusterState, w io.Writer) {
+			c.clusterState = state
+			c.outputResult(w)
+		})
 	}
 
-	// Get pod info
-	err = getK8sInfo(restConfig, serviceName, namespace, c.k8sInfo)
+	state, err := query.collect()
 	if err != nil {
 		return err
 	}
-
-	var pfwd *portforwarder.PortForwarder
-	if c.verbose {
-		pfwd = portforwarder.New(restConfig, c.streams.Out, c.streams.ErrOut)
-	} else {
-		pfwd = portforwarder.New(restConfig, nil, nil)
-
-		// Silence K8s errors, like connection refuse
-		logKubeError := func(err error) {}
-		runtime.ErrorHandlers = []func(error){logKubeError}
-	}
-
-	// Query all pods/redis instances
-	ch := make(chan QueryRedisResult)
-	for _, pod := range c.k8sInfo.Pods {
-		go func(podName string, podPort int, ch chan QueryRedisResult) {
-			redisInfo, clusterNodes, clusterSlots, err := redisutils.QueryRedis(pfwd, namespace, podName, podPort)
-			ch <- QueryRedisResult{
-				PodName: podName,
-				Info:    redisInfo,
-				Nodes:   clusterNodes,
-				Slots:   clusterSlots,
-				Error:   err,
-			}
-		}(pod.Name, redisutils.RedisPort, ch)
-	}
-
-	// Collect results from all pods/redis instances
-	for range c.k8sInfo.Pods {
-		queryResult := <-ch
-
-		if queryResult.Error != nil {
-			pod := queryResult.PodName
-			c.remarks[pod] = append(c.remarks[pod], "RedisUnavailable")
-			c.errors[pod] = append(c.errors[pod],
-				fmt.Sprintf("Failed to get Redis information: %s", queryResult.Error))
-		}
-		if queryResult.Info != nil {
-			c.redisInfo[queryResult.PodName] = queryResult.Info
-		}
-		if queryResult.Nodes != nil {
-			c.redisNodes[queryResult.PodName] = queryResult.Nodes
-		}
-		if queryResult.Slots != nil {
-			c.redisSlots[queryResult.PodName] = queryResult.Slots
-		}
-	}
+	c.clusterState = state
 
 	//	Display result
-	c.outputResult()
+	if isMachineOutput(c.output) {
+		return c.printOutput()
+	}
+	c.outputResult(c.streams.Out)
 
 	return nil
 }
 
-func (c *nodesCmd) outputResult() {
-	// Convert PodInfo to a ordered list
-	podList := []k8s.PodInfo{}
-	for _, pod := range c.k8sInfo.Pods {
-		podList = append(podList, pod)
+func (c *nodesCmd) printOutput() error {
+	pods := c.filter.filterPods(c.clusterState, c.podListByName())
+	list := nodeList{
+		outputList:    *c.newOutputList("NodeList", c.podOutputs(pods)),
+		NodesAnalysis: c.analyzeClusterNodes(),
+		Membership:    c.analyzeMembership(),
+		OpenSlots:     c.openSlots(),
 	}
+	return printOutput(list, c.output, c.streams.Out)
+}
 
-	// Sort by host and ip
-	sort.Slice(podList, func(i, j int) bool {
-		if podList[i].Host != podList[j].Host {
-			return podList[i].Host < podList[j].Host
-		}
-		return podList[i].IP < podList[j].IP
-	})
-
-	if len(podList) == 0 {
+func (c *nodesCmd) outputResult(out io.Writer) {
+	if len(c.k8sInfo.Pods) == 0 {
 		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any pod information to show..")
 		return
 	}
 
-	w := tabwriter.NewWriter(c.streams.Out, 5, 3, 2, ' ', 0)
+	// Pods ordered by host and ip, or by selected order
+	podList := c.filter.filterPods(c.clusterState, c.podList())
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
 	defer w.Flush()
 
+	wide := c.output == outputWide
+
 	fmt.Fprintln(w, "\t\t\t\t\t\tSLOT\tCLUSTER\t")
-	fmt.Fprintln(w, "HOST\tPODNAME\tIP\tROLE\tKEYS\tSLOTS\tRANGES\tSTATE\tUPTIME\tREMARKS")
+	if wide {
+		fmt.Fprintln(w, "HOST\tPODNAME\tIP\tROLE\tKEYS\tSLOTS\tRANGES\tSTATE\tUPTIME\t"+
+			"NODE-ID\tMASTER\tCONFIG-EPOCH\tLINK\tRESTARTS\tSTART-TIME\tZONE\tREMARKS")
+	} else {
+		fmt.Fprintln(w, "HOST\tPODNAME\tIP\tROLE\tKEYS\tSLOTS\tRANGES\tSTATE\tUPTIME\tREMARKS")
+	}
 
 	for _, p := range podList {
 		podName := p.Name
@@ -227,25 +197,58 @@ func (c *nodesCmd) outputResult() {
 			remarks += info
 		}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			p.Host, p.Name, p.IP, role, keys, slots, slotranges, state, uptime, remarks)
+		if wide {
+			nodeID, master, epoch, link := c.clusterNodeColumns(podName)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+				p.Host, p.Name, p.IP, role, keys, slots, slotranges, state, uptime,
+				nodeID, master, epoch, link, p.Restarts, p.StartTime, p.Zone, remarks)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				p.Host, p.Name, p.IP, role, keys, slots, slotranges, state, uptime, remarks)
+		}
 	}
 
-	// Print errors
-	addNewline := true
-	for _, p := range podList {
-		for _, text := range c.errors[p.Name] {
-			if addNewline {
-				fmt.Fprintf(w, "\n")
-				addNewline = false
-			}
-			fmt.Fprintf(w, "%s:\t%s\n", p.Name, text)
+	// Print findings from comparing the CLUSTER NODES views
+	analysis := c.analyzeClusterNodes()
+	if findings := analysis.findings(); len(findings) > 0 {
+		fmt.Fprintf(w, "\nCLUSTER NODES analysis:\n")
+		for _, text := range findings {
+			fmt.Fprintf(w, "%s\n", text)
+		}
+	}
+
+	// Print cluster members without pods, and pods that are not members
+	membership := c.analyzeMembership()
+	if findings := membership.findings(); len(findings) > 0 {
+		fmt.Fprintf(w, "\nCluster membership:\n")
+		for _, text := range findings {
+			fmt.Fprintf(w, "%s\n", text)
 		}
 	}
+
+	// Print errors
+	c.outputErrors(w)
+}
+
+// clusterNodeColumns returns the CLUSTER NODES record for the pod itself,
+// with the master ID resolved to the masters pod name
+func (c *nodesCmd) clusterNodeColumns(podName string) (string, string, string, string) {
+	nodes := c.redisNodes[podName]
+	self, found := nodes.GetSelf()
+	if !found {
+		return "", "", "", ""
+	}
+
+	master := c.masterPodName(podName)
+	return self.ID, master, strconv.FormatInt(self.ConfigEpoch, 10), self.LinkState
 }
 
 func slotsCount(ip string, slots redisutils.ClusterSlots) (int, int) {
-	ep := fmt.Sprintf("%s:%d", ip, redisutils.RedisPort)
+	return addrSlotsCount(fmt.Sprintf("%s:%d", ip, redisutils.RedisPort), slots)
+}
+
+// addrSlotsCount returns the number of slots and slot ranges served by given address
+func addrSlotsCount(ep string, slots redisutils.ClusterSlots) (int, int) {
 	slotsCount := 0
 	slotrangesCount := 0
 	for _, slot := range slots {
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+)
+
+// A slot in migrating or importing state, found in the CLUSTER NODES record of a pod itself
+type openSlot struct {
+	Slot  int    `json:"slot"`
+	State string `json:"state"`
+	Pod   string `json:"pod"`
+	// The pod that the slot is migrating to or importing from, or the node ID when unknown
+	Peer string `json:"peer"`
+	// The peer does not have the slot in the opposite state, like after an interrupted reshard
+	Unpaired bool `json:"unpaired,omitempty"`
+}
+
+// openSlots returns the open slots of all pods, ordered by slot
+func (s *clusterState) openSlots() []openSlot {
+	result := []openSlot{}
+	for _, p := range s.podList() {
+		nodes := s.redisNodes[p.Name]
+		self, found := nodes.GetSelf()
+		if !found {
+			continue
+		}
+		for _, o := range self.OpenSlots() {
+			result = append(result, openSlot{
+				Slot:  o.Slot,
+				State: o.State,
+				Pod:   p.Name,
+				Peer:  s.nodePodName(p.Name, o.NodeID),
+			})
+		}
+	}
+
+	// A migrating slot should be importing in the peer, and the other way around
+	for i := range result {
+		if _, known := s.redisNodes[result[i].Peer]; !known {
+			continue // Not able to check the peer
+		}
+		opposite := redisutils.SlotImporting
+		if result[i].State == redisutils.SlotImporting {
+			opposite = redisutils.SlotMigrating
+		}
+		result[i].Unpaired = true
+		for _, o := range result {
+			if o.Slot == result[i].Slot && o.State == opposite &&
+				o.Pod == result[i].Peer && o.Peer == result[i].Pod {
+				result[i].Unpaired = false
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Slot < result[j].Slot
+	})
+	return result
+}
+
+// remark returns the remark shown for the pod with the open slot
+func (o *openSlot) remark() string {
+	if o.State == redisutils.SlotMigrating {
+		return fmt.Sprintf("*migrating to pod %s*", o.Peer)
+	}
+	return fmt.Sprintf("*importing from pod %s*", o.Peer)
+}
+
+// describe returns a description of the open slot
+func (o *openSlot) describe() string {
+	if o.State == redisutils.SlotMigrating {
+		text := fmt.Sprintf("%s is migrating the slot to %s", o.Pod, o.Peer)
+		if o.Unpaired {
+			text += fmt.Sprintf(", but %s is not importing it", o.Peer)
+		}
+		return text
+	}
+	text := fmt.Sprintf("%s is importing the slot from %s", o.Pod, o.Peer)
+	if o.Unpaired {
+		text += fmt.Sprintf(", but %s is not migrating it", o.Peer)
+	}
+	return text
+}
+
+// addOpenSlotRemarks adds a remark to the pods with open slots, once per peer and direction
+func (s *clusterState) addOpenSlotRemarks() {
+	added := map[string]bool{}
+	for _, o := range s.openSlots() {
+		remark := o.remark()
+		if !added[o.Pod+remark] {
+			added[o.Pod+remark] = true
+			s.remarks[o.Pod] = append(s.remarks[o.Pod], remark)
+		}
+	}
+}
+
+// nodePodName returns the pod name of a node ID, given the CLUSTER NODES view of a pod.
+// The node ID is returned when the pod is unknown.
+func (s *clusterState) nodePodName(viewPodName string, id string) string {
+	nodes := s.redisNodes[viewPodName]
+	if n, ok := nodes.GetNode(id); ok {
+		if name := s.k8sInfo.GetPodInfo(n.Addr).Name; name != "" {
+			return name
+		}
+	}
+	return id
+}
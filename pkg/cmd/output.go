@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/printers"
+)
+
+// Schema version of the machine-readable output, bump on incompatible changes
+const outputAPIVersion = "kubectl-rediscluster/v1"
+
+// Output formats
+const (
+	outputJSON          = "json"
+	outputYAML          = "yaml"
+	outputWide          = "wide"
+	outputJSONPath      = "jsonpath"
+	outputGoTemplate    = "go-template"
+	outputCustomColumns = "custom-columns"
+)
+
+// Output formats supported by all commands that produce an output document
+var outputFormats = []string{outputJSON, outputYAML, outputJSONPath, outputGoTemplate, outputCustomColumns}
+
+// Help text for the output flag
+const outputUsage = "Output format. One of: json|yaml|jsonpath=...|go-template=...|custom-columns=..."
+
+// Help text for the output flag of commands that also have a wide table
+const outputWideUsage = "Output format. One of: json|yaml|wide|jsonpath=...|go-template=...|custom-columns=..."
+
+// Output document shared by all commands. Commands with additional top-level
+// fields embed it in an own document type.
+type outputList struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   outputMetadata `json:"metadata"`
+	Items      interface{}    `json:"items"`
+}
+
+type outputMetadata struct {
+	Namespace string `json:"namespace"`
+	Service   string `json:"service"`
+}
+
+type podOutput struct {
+	Pod           string              `json:"pod"`
+	IP            string              `json:"ip"`
+	Host          string              `json:"host"`
+	Zone          string              `json:"zone,omitempty"`
+	Region        string              `json:"region,omitempty"`
+	MemoryRequest int64               `json:"memoryRequest,omitempty"`
+	MemoryLimit   int64               `json:"memoryLimit,omitempty"`
+	Restarts      int                 `json:"restarts"`
+	StartTime     string              `json:"startTime,omitempty"`
+	Role          string              `json:"role,omitempty"`
+	Keys          *int64              `json:"keys,omitempty"`
+	Slots         int                 `json:"slots"`
+	SlotRanges    int                 `json:"slotRanges"`
+	ClusterState  string              `json:"clusterState,omitempty"`
+	Info          map[string]string   `json:"info,omitempty"`
+	ClusterNodes  []clusterNodeOutput `json:"clusterNodes,omitempty"`
+	ClusterSlots  []slotRangeOutput   `json:"clusterSlots,omitempty"`
+	Remarks       []string            `json:"remarks,omitempty"`
+	Errors        []string            `json:"errors,omitempty"`
+}
+
+type clusterNodeOutput struct {
+	ID          string   `json:"id"`
+	Addr        string   `json:"addr"`
+	Pod         string   `json:"pod,omitempty"`
+	Flags       []string `json:"flags"`
+	MasterID    string   `json:"masterId,omitempty"`
+	PingSent    int64    `json:"pingSent"`
+	PongRecv    int64    `json:"pongRecv"`
+	ConfigEpoch int64    `json:"configEpoch"`
+	LinkState   string   `json:"linkState"`
+	Slots       []string `json:"slots,omitempty"`
+}
+
+type slotRangeOutput struct {
+	Start   int              `json:"start"`
+	End     int              `json:"end"`
+	Nodes   []slotNodeOutput `json:"nodes"`
+	Remarks []string         `json:"remarks,omitempty"`
+}
+
+type slotNodeOutput struct {
+	Role string `json:"role"`
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+	Pod  string `json:"pod,omitempty"`
+	Host string `json:"host,omitempty"`
+	Zone string `json:"zone,omitempty"`
+}
+
+// parseOutput splits an output flag value like jsonpath={.items} into format and argument
+func parseOutput(output string) (string, string) {
+	parts := strings.SplitN(output, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// validateOutput checks that the given output format is supported
+func validateOutput(output string, allowed ...string) error {
+	if output == "" {
+		return nil
+	}
+	format, _ := parseOutput(output)
+	for _, a := range allowed {
+		if format == a {
+			if isMachineOutput(output) {
+				// Verify templates and expressions
+				_, err := newPrinter(output)
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format: %s, allowed formats are: %s",
+		format, strings.Join(allowed, "|"))
+}
+
+// isMachineOutput returns true when the output should not be mixed with other text
+func isMachineOutput(output string) bool {
+	return output != "" && output != outputWide
+}
+
+// newOutputList creates an output document of given kind
+func (s *clusterState) newOutputList(kind string, items interface{}) *outputList {
+	return &outputList{
+		APIVersion: outputAPIVersion,
+		Kind:       kind,
+		Metadata: outputMetadata{
+			Namespace: s.namespace,
+			Service:   s.serviceName,
+		},
+		Items: items,
+	}
+}
+
+// podOutputs returns all collected information for given pods, in the given order
+func (s *clusterState) podOutputs(pods []k8s.PodInfo) []podOutput {
+	result := []podOutput{}
+	zones := s.zoneMajorities()
+	for _, p := range pods {
+		po := podOutput{
+			Pod:           p.Name,
+			IP:            p.IP,
+			Host:          p.Host,
+			Zone:          p.Zone,
+			Region:        p.Region,
+			MemoryRequest: p.MemoryRequest,
+			MemoryLimit:   p.MemoryLimit,
+			Restarts:      p.Restarts,
+			StartTime:     p.StartTime,
+			Info:          s.redisInfo[p.Name],
+			Remarks:       append([]string{}, s.remarks[p.Name]...),
+			Errors:        s.errors[p.Name],
+		}
+		if p.Info != "" {
+			po.Remarks = append(po.Remarks, p.Info)
+		}
+		if info, ok := s.redisInfo[p.Name]; ok {
+			po.Keys = s.optionalInfoValue(p.Name, "keys")
+			po.ClusterState = info["cluster_state"]
+		}
+		if nodes, ok := s.redisNodes[p.Name]; ok {
+			if self, found := nodes.GetSelf(); found {
+				po.Role = self.Role()
+			}
+			po.ClusterNodes = s.clusterNodeOutputs(nodes)
+		}
+		if slots, ok := s.redisSlots[p.Name]; ok {
+			po.Slots, po.SlotRanges = slotsCount(p.IP, slots)
+			po.ClusterSlots = s.slotRangeOutputs(slots, zones)
+		}
+		result = append(result, po)
+	}
+	return result
+}
+
+func (s *clusterState) clusterNodeOutputs(nodes redisutils.ClusterNodes) []clusterNodeOutput {
+	result := []clusterNodeOutput{}
+	for _, n := range nodes.List() {
+		result = append(result, clusterNodeOutput{
+			ID:          n.ID,
+			Addr:        n.Addr,
+			Pod:         s.k8sInfo.GetPodInfo(n.Addr).Name,
+			Flags:       n.Flags,
+			MasterID:    n.MasterID,
+			PingSent:    n.PingSent,
+			PongRecv:    n.PongRecv,
+			ConfigEpoch: n.ConfigEpoch,
+			LinkState:   n.LinkState,
+			Slots:       n.Slots,
+		})
+	}
+	return result
+}
+
+func (s *clusterState) slotRangeOutputs(slots redisutils.ClusterSlots, zones []zoneMajority) []slotRangeOutput {
+	result := []slotRangeOutput{}
+	for _, slot := range slots {
+		so := slotRangeOutput{
+			Start: slot.Start,
+			End:   slot.End,
+			Nodes: []slotNodeOutput{},
+		}
+		if remarks := s.slotRangeRemarks(slot, zones); len(remarks) > 0 {
+			so.Remarks = remarks
+		}
+		for i, node := range slot.Nodes {
+			role := "replica"
+			if i == 0 {
+				role = "master"
+			}
+			podInfo := s.k8sInfo.GetPodInfo(node.Addr)
+			so.Nodes = append(so.Nodes, slotNodeOutput{
+				Role: role,
+				ID:   node.ID,
+				Addr: node.Addr,
+				Pod:  podInfo.Name,
+				Host: podInfo.Host,
+				Zone: podInfo.Zone,
+			})
+		}
+		result = append(result, so)
+	}
+	return result
+}
+
+// toUnstructured converts an output document to an object usable by the kubectl printers
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	content := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep integers as is, like keys and epochs
+	if err := decoder.Decode(&content); err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: convertNumbers(content).(map[string]interface{})}, nil
+}
+
+// convertNumbers replaces json.Number with int64 or float64, usable in templates and filters
+func convertNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = convertNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = convertNumbers(val)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// newPrinter creates a kubectl-style printer for the given machine-readable format
+func newPrinter(output string) (printers.ResourcePrinter, error) {
+	format, arg := parseOutput(output)
+	switch format {
+	case outputJSON:
+		return &printers.JSONPrinter{}, nil
+	case outputYAML:
+		return &printers.YAMLPrinter{}, nil
+	case outputJSONPath:
+		if arg == "" {
+			return nil, fmt.Errorf("jsonpath template format specified but no template given")
+		}
+		p, err := printers.NewJSONPathPrinter(arg)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing jsonpath %s, %v", arg, err)
+		}
+		p.AllowMissingKeys(true)
+		return p, nil
+	case outputGoTemplate:
+		if arg == "" {
+			return nil, fmt.Errorf("go-template format specified but no template given")
+		}
+		p, err := printers.NewGoTemplatePrinter([]byte(arg))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template %s, %v", arg, err)
+		}
+		p.AllowMissingKeys(true)
+		return p, nil
+	case outputCustomColumns:
+		return newCustomColumnsPrinter(arg)
+	}
+	return nil, fmt.Errorf("unsupported output format: %s", format)
+}
+
+// printOutput prints an output document using the given machine-readable format
+func printOutput(obj interface{}, output string, out io.Writer) error {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	printer, err := newPrinter(output)
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(u, out)
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Remarks added by the persistence analysis
+const (
+	remarkBgsaveFailed     = "*bgsave failed*"
+	remarkAOFWriteFailed   = "*aof write failed*"
+	remarkAOFRewriteFailed = "*aof rewrite failed*"
+	remarkNeverPersisted   = "*never persisted*"
+)
+
+type persistenceCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+
+	*clusterState
+}
+
+// RDB and AOF persistence state of a pod, from Redis INFO
+type persistenceInfo struct {
+	Pod                  string   `json:"pod"`
+	Role                 string   `json:"role,omitempty"`
+	Loading              bool     `json:"loading"`
+	LastSaveTime         int64    `json:"lastSaveTime"`
+	LastSaveSecondsAgo   int64    `json:"lastSaveSecondsAgo"`
+	ChangesSinceLastSave int64    `json:"changesSinceLastSave"`
+	BgsaveInProgress     bool     `json:"bgsaveInProgress"`
+	LastBgsaveStatus     string   `json:"lastBgsaveStatus"`
+	AOFEnabled           bool     `json:"aofEnabled"`
+	AOFRewriteInProgress bool     `json:"aofRewriteInProgress"`
+	AOFLastRewriteStatus string   `json:"aofLastRewriteStatus,omitempty"`
+	AOFLastWriteStatus   string   `json:"aofLastWriteStatus,omitempty"`
+	Remarks              []string `json:"remarks,omitempty"`
+}
+
+// NewPersistenceCmd initialize and creates a Cobra command
+func NewPersistenceCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &persistenceCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "persistence [service-name] [flags]",
+		Short: "Show the RDB and AOF persistence status of a Redis Cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *persistenceCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *persistenceCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *persistenceCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return printOutput(c.newOutputList("PersistenceList", c.persistenceInfos()), c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *persistenceCmd) outputResult(out io.Writer) {
+	if len(c.redisInfo) == 0 {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any Redis INFO data to show..")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\t\t\tLAST\t\tBGSAVE\tBGSAVE\t\tAOF\tAOF\t")
+	fmt.Fprintln(w, "PODNAME\tROLE\tLOADING\tSAVE\tCHANGES\tRUNNING\tSTATUS\tAOF\tREWRITE\tSTATUS\tREMARKS")
+	for _, p := range c.persistenceInfos() {
+		lastSave := ""
+		if p.LastSaveTime > 0 && p.LastSaveSecondsAgo >= 0 {
+			lastSave = (time.Duration(p.LastSaveSecondsAgo) * time.Second).String()
+		}
+		aofStatus := ""
+		if p.AOFEnabled {
+			aofStatus = p.AOFLastWriteStatus
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			p.Pod, p.Role, formatYesNo(p.Loading), lastSave, p.ChangesSinceLastSave,
+			formatYesNo(p.BgsaveInProgress), p.LastBgsaveStatus, formatYesNo(p.AOFEnabled),
+			formatYesNo(p.AOFRewriteInProgress), aofStatus, strings.Join(p.Remarks, ", "))
+	}
+
+	c.outputErrors(w)
+}
+
+// persistenceInfos returns the persistence state of all queried pods. A pod is flagged when
+// the last bgsave, AOF write or AOF rewrite failed, or when it has changes but no AOF and
+// no RDB save since it started, since the changes are then lost when the pod is restarted.
+func (s *clusterState) persistenceInfos() []persistenceInfo {
+	result := []persistenceInfo{}
+	for _, pod := range s.podList() {
+		info, ok := s.redisInfo[pod.Name]
+		if !ok {
+			continue
+		}
+		p := persistenceInfo{
+			Pod:                  pod.Name,
+			Role:                 s.podRole(pod.Name),
+			Loading:              info["loading"] == "1",
+			LastSaveTime:         s.podInfoValue(pod.Name, "rdb_last_save_time"),
+			ChangesSinceLastSave: s.podInfoValue(pod.Name, "rdb_changes_since_last_save"),
+			BgsaveInProgress:     info["rdb_bgsave_in_progress"] == "1",
+			LastBgsaveStatus:     info["rdb_last_bgsave_status"],
+			AOFEnabled:           info["aof_enabled"] == "1",
+			AOFRewriteInProgress: info["aof_rewrite_in_progress"] == "1",
+			AOFLastRewriteStatus: info["aof_last_bgrewrite_status"],
+			AOFLastWriteStatus:   info["aof_last_write_status"],
+		}
+		now := s.serverTime(pod.Name) / 1000
+		p.LastSaveSecondsAgo = now - p.LastSaveTime
+
+		if p.LastBgsaveStatus != "" && p.LastBgsaveStatus != "ok" {
+			p.Remarks = append(p.Remarks, remarkBgsaveFailed)
+		}
+		if p.AOFEnabled && p.AOFLastWriteStatus != "" && p.AOFLastWriteStatus != "ok" {
+			p.Remarks = append(p.Remarks, remarkAOFWriteFailed)
+		}
+		if p.AOFEnabled && p.AOFLastRewriteStatus != "" && p.AOFLastRewriteStatus != "ok" {
+			p.Remarks = append(p.Remarks, remarkAOFRewriteFailed)
+		}
+
+		// Redis sets the last save time to the start time when no save has been done,
+		// allow a second of rounding between the start time and the uptime
+		uptime := s.podInfoValue(pod.Name, "uptime_in_seconds")
+		savedSinceStart := uptime >= 0 && p.LastSaveSecondsAgo < uptime-1
+		if saves := s.podInfoValue(pod.Name, "rdb_saves"); saves >= 0 {
+			savedSinceStart = saves > 0 // Available from Redis 7.0
+		}
+		if !p.AOFEnabled && !savedSinceStart && p.ChangesSinceLastSave > 0 {
+			p.Remarks = append(p.Remarks, remarkNeverPersisted)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func formatYesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/portforwarder"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// clusterQuery collects information from K8s and all Redis instances of a Redis Cluster
+type clusterQuery struct {
+	namespace   string
+	serviceName string
+	restConfig  *rest.Config
+	pfwd        *portforwarder.PortForwarder
+	errOut      io.Writer
+
+	// List the K8s nodes to get the zones of the pods, when used by the command
+	withZones bool
+
+	// Keep portforwards and Redis connections open between queries, like when watching
+	keepConnections bool
+	connections     map[string]*podConnection
+}
+
+type podConnection struct {
+	ip   string
+	conn *redisutils.Connection
+}
+
+// Type used when transferring result and connection from a pod query
+type podQueryResult struct {
+	QueryRedisResult
+	ip   string
+	conn *redisutils.Connection
+}
+
+// newClusterQuery finds the namespace and service to query, given as argument or guessed
+func newClusterQuery(configFlags *genericclioptions.ConfigFlags, streams *genericclioptions.IOStreams,
+	args []string, verbose bool, infoOut io.Writer) (*clusterQuery, error) {
+	namespace, err := k8s.CurrentNamespace(configFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := ""
+	if len(args) > 0 {
+		serviceName = args[0]
+	} else {
+		serviceName, err = k8s.FindServiceUsingPort(restConfig, namespace, redisutils.RedisPort)
+		if err != nil {
+			return nil, fmt.Errorf("%s\n\nPlease provide a service name", err)
+		}
+		fmt.Fprintf(infoOut, "Using service name: %s\n", serviceName)
+	}
+
+	var pfwd *portforwarder.PortForwarder
+	if verbose {
+		pfwd = portforwarder.New(restConfig, streams.Out, streams.ErrOut)
+	} else {
+		pfwd = portforwarder.New(restConfig, nil, nil)
+
+		// Silence K8s errors, like connection refuse
+		logKubeError := func(err error) {}
+		runtime.ErrorHandlers = []func(error){logKubeError}
+	}
+
+	return &clusterQuery{
+		namespace:   namespace,
+		serviceName: serviceName,
+		restConfig:  restConfig,
+		pfwd:        pfwd,
+		errOut:      streams.ErrOut,
+		connections: make(map[string]*podConnection),
+	}, nil
+}
+
+// collect queries K8s and all pods/redis instances
+func (q *clusterQuery) collect() (*clusterState, error) {
+	state := newClusterState()
+	state.namespace = q.namespace
+	state.serviceName = q.serviceName
+
+	// Get pod info
+	err := getK8sInfo(q.restConfig, q.serviceName, q.namespace, state.k8sInfo)
+	if err != nil {
+		return nil, err
+	}
+	if q.withZones {
+		getZoneInfo(q.restConfig, state.k8sInfo, q.errOut)
+	}
+
+	// Query all pods/redis instances
+	ch := make(chan podQueryResult)
+	for _, pod := range state.k8sInfo.Pods {
+		var conn *redisutils.Connection
+		if pc, ok := q.connections[pod.Name]; ok && pc.ip == pod.IP {
+			conn = pc.conn
+			delete(q.connections, pod.Name)
+		}
+		go func(pod k8s.PodInfo, conn *redisutils.Connection, ch chan podQueryResult) {
+			ch <- q.queryPod(pod, conn)
+		}(pod, conn, ch)
+	}
+
+	// Connections left are to pods that are gone or changed IP
+	q.close()
+
+	// Collect results from all pods/redis instances
+	for range state.k8sInfo.Pods {
+		result := <-ch
+		state.addQueryResult(result.QueryRedisResult)
+		if result.conn != nil {
+			q.connections[result.PodName] = &podConnection{ip: result.ip, conn: result.conn}
+		}
+	}
+
+	// Compare the views of all pods
+	state.addNodesRemarks()
+	state.addMembershipRemarks()
+	state.addOpenSlotRemarks()
+
+	return state, nil
+}
+
+// queryPod queries a Redis instance, using a kept connection when available
+func (q *clusterQuery) queryPod(pod k8s.PodInfo, conn *redisutils.Connection) podQueryResult {
+	var err error
+	if conn == nil {
+		conn, err = redisutils.Connect(q.pfwd, q.namespace, pod.Name, redisutils.RedisPort)
+		if err != nil {
+			return podQueryResult{QueryRedisResult: QueryRedisResult{PodName: pod.Name, Error: err}}
+		}
+	}
+
+	redisInfo, clusterNodes, clusterSlots, err := conn.Query()
+	if err != nil || !q.keepConnections {
+		conn.Close()
+		conn = nil
+	}
+
+	return podQueryResult{
+		QueryRedisResult: QueryRedisResult{
+			PodName: pod.Name,
+			Info:    redisInfo,
+			Nodes:   clusterNodes,
+			Slots:   clusterSlots,
+			Error:   err,
+		},
+		ip:   pod.IP,
+		conn: conn,
+	}
+}
+
+// Result from running a function on the Redis instance of a pod
+type podCommandResult struct {
+	pod   k8s.PodInfo
+	value interface{}
+	err   error
+}
+
+// runOnPods runs the function on the Redis instance of each given pod in parallel, using the
+// connection kept from the collect when available. The results are returned in the order of the pods.
+func (q *clusterQuery) runOnPods(pods []k8s.PodInfo,
+	fn func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error)) []podCommandResult {
+	results := make([]podCommandResult, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		var conn *redisutils.Connection
+		if pc, ok := q.connections[pod.Name]; ok && pc.ip == pod.IP {
+			conn = pc.conn
+		}
+		wg.Add(1)
+		go func(i int, pod k8s.PodInfo, conn *redisutils.Connection) {
+			defer wg.Done()
+			results[i].pod = pod
+			if conn == nil {
+				var err error
+				conn, err = redisutils.Connect(q.pfwd, q.namespace, pod.Name, redisutils.RedisPort)
+				if err != nil {
+					results[i].err = err
+					return
+				}
+				defer conn.Close()
+			}
+			results[i].value, results[i].err = fn(pod, conn)
+		}(i, pod, conn)
+	}
+	wg.Wait()
+	return results
+}
+
+// close all kept connections
+func (q *clusterQuery) close() {
+	for name, pc := range q.connections {
+		pc.conn.Close()
+		delete(q.connections, name)
+	}
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Remarks added by the replication analysis
+const (
+	remarkLinkDown    = "*link down*"
+	remarkLagging     = "*lagging*"
+	remarkResyncing   = "*resyncing*"
+	remarkUnreachable = "*unreachable*"
+)
+
+// Default replication offset lag, in bytes, above which a replica is considered lagging
+const defaultMaxLag = 1024 * 1024
+
+type replicationCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	maxLag      int64
+
+	*clusterState
+}
+
+// Replication state of a master or a replica, from Redis INFO
+type replicationInfo struct {
+	Pod  string `json:"pod"`
+	Role string `json:"role"`
+
+	// Replicas only
+	Master           string `json:"master,omitempty"`
+	LinkStatus       string `json:"linkStatus,omitempty"`
+	LastIOSecondsAgo *int64 `json:"lastIOSecondsAgo,omitempty"`
+	Lag              *int64 `json:"lag,omitempty"`
+	SyncInProgress   bool   `json:"syncInProgress,omitempty"`
+
+	// Masters only, the number of syncs served to replicas
+	ConnectedReplicas *int64 `json:"connectedReplicas,omitempty"`
+	SyncFull          *int64 `json:"syncFull,omitempty"`
+	SyncPartialOK     *int64 `json:"syncPartialOk,omitempty"`
+	SyncPartialErr    *int64 `json:"syncPartialErr,omitempty"`
+
+	Offset  int64    `json:"offset"` // -1 when unknown
+	Remarks []string `json:"remarks,omitempty"`
+}
+
+// NewReplicationCmd initialize and creates a Cobra command
+func NewReplicationCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &replicationCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "replication [service-name] [flags]",
+		Short: "Show the replication status between masters and replicas of a Redis Cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().Int64Var(&c.maxLag, "max-lag", defaultMaxLag, "Replication offset lag, in bytes, above which a replica is considered lagging")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *replicationCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *replicationCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.maxLag < 0 {
+		return fmt.Errorf("max-lag can not be negative, got %d", c.maxLag)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *replicationCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		return printOutput(c.newOutputList("ReplicationList", c.replicationInfos(c.maxLag)), c.output, c.streams.Out)
+	}
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *replicationCmd) outputResult(out io.Writer) {
+	if len(c.redisInfo) == 0 {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any Redis INFO data to show..")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\t\t\t\tLAST\t\tLAG\tSYNC\tSYNC\tSYNC\t")
+	fmt.Fprintln(w, "PODNAME\tROLE\tMASTER\tLINK\tIO\tOFFSET\tBYTES\tFULL\tPARTIAL\tFAILED\tREMARKS")
+	for _, r := range c.replicationInfos(c.maxLag) {
+		lastIO := ""
+		if r.LastIOSecondsAgo != nil && *r.LastIOSecondsAgo >= 0 {
+			lastIO = fmt.Sprintf("%ds", *r.LastIOSecondsAgo)
+		}
+		offset := ""
+		if r.Offset >= 0 {
+			offset = strconv.FormatInt(r.Offset, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Pod, r.Role, r.Master, r.LinkStatus, lastIO, offset, formatOptional(r.Lag),
+			formatOptional(r.SyncFull), formatOptional(r.SyncPartialOK), formatOptional(r.SyncPartialErr),
+			strings.Join(r.Remarks, ", "))
+	}
+
+	c.outputErrors(w)
+}
+
+// replicationInfos returns the replication state of all pods, each master followed by its replicas.
+// Replicas with an unknown master are listed last. Pods without Redis INFO are included with
+// the role and master given by the CLUSTER NODES view of the other pods.
+func (s *clusterState) replicationInfos(maxLag int64) []replicationInfo {
+	masters := []replicationInfo{}
+	replicas := map[string][]replicationInfo{}
+	orphans := []replicationInfo{}
+
+	for _, p := range s.podList() {
+		info, ok := s.redisInfo[p.Name]
+		if !ok {
+			r := replicationInfo{Pod: p.Name, Offset: -1, Remarks: []string{remarkUnreachable}}
+			if viewPod, node, found := s.viewedNode(p.IP); found {
+				r.Role = node.Role()
+				if node.MasterID != "" {
+					r.Master = s.nodePodName(viewPod, node.MasterID)
+				}
+			}
+			if _, known := s.redisInfo[r.Master]; known {
+				replicas[r.Master] = append(replicas[r.Master], r)
+			} else if r.Role == roleMaster {
+				masters = append(masters, r)
+			} else {
+				orphans = append(orphans, r)
+			}
+			continue
+		}
+		r := replicationInfo{Pod: p.Name, Role: s.podRole(p.Name)}
+
+		if r.Role != roleReplica {
+			r.Offset = s.podInfoValue(p.Name, "master_repl_offset")
+			r.ConnectedReplicas = s.optionalInfoValue(p.Name, "connected_slaves")
+			r.SyncFull = s.optionalInfoValue(p.Name, "sync_full")
+			r.SyncPartialOK = s.optionalInfoValue(p.Name, "sync_partial_ok")
+			r.SyncPartialErr = s.optionalInfoValue(p.Name, "sync_partial_err")
+			masters = append(masters, r)
+			continue
+		}
+
+		r.Master = s.masterPodName(p.Name)
+		r.LinkStatus = info["master_link_status"]
+		r.LastIOSecondsAgo = s.optionalInfoValue(p.Name, "master_last_io_seconds_ago")
+		r.SyncInProgress = info["master_sync_in_progress"] == "1"
+		r.Offset = s.podInfoValue(p.Name, "slave_repl_offset")
+		if masterOffset := s.podInfoValue(r.Master, "master_repl_offset"); masterOffset >= 0 && r.Offset >= 0 {
+			lag := masterOffset - r.Offset
+			if lag < 0 {
+				lag = 0 // The replica got newer data after the master was queried
+			}
+			r.Lag = &lag
+		}
+
+		if r.LinkStatus != "up" {
+			r.Remarks = append(r.Remarks, remarkLinkDown)
+		}
+		if r.Lag != nil && *r.Lag > maxLag {
+			r.Remarks = append(r.Remarks, remarkLagging)
+		}
+		if r.SyncInProgress {
+			r.Remarks = append(r.Remarks, remarkResyncing)
+		}
+
+		if _, known := s.redisInfo[r.Master]; known {
+			replicas[r.Master] = append(replicas[r.Master], r)
+		} else {
+			orphans = append(orphans, r)
+		}
+	}
+
+	result := []replicationInfo{}
+	for _, m := range masters {
+		result = append(result, m)
+		result = append(result, replicas[m.Pod]...)
+		delete(replicas, m.Pod)
+	}
+	// Replicas of a pod that reports itself as replica, like in a chained replication
+	for _, p := range s.podList() {
+		result = append(result, replicas[p.Name]...)
+	}
+	return append(result, orphans...)
+}
+
+// viewedNode returns how a pod with given IP is seen in the CLUSTER NODES view of
+// another pod, together with the name of that pod. Used when the pod itself can't be queried.
+func (s *clusterState) viewedNode(ip string) (string, redisutils.ClusterNode, bool) {
+	for _, p := range s.podList() {
+		if p.IP == ip {
+			continue
+		}
+		nodes := s.redisNodes[p.Name]
+		if node, found := nodes.GetNodeByIP(ip); found {
+			return p.Name, node, true
+		}
+	}
+	return "", redisutils.ClusterNode{}, false
+}
+
+// optionalInfoValue returns a numeric Redis INFO field, or nil when not available
+func (s *clusterState) optionalInfoValue(podName string, field string) *int64 {
+	value, err := strconv.ParseInt(s.redisInfo[podName][field], 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+func formatOptional(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Supported report formats
+const (
+	formatHTML     = "html"
+	formatMarkdown = "markdown"
+)
+
+// Explanations of the remarks shown by the commands
+var remarkExplanations = map[string]string{
+	"RedisUnavailable":        "The Redis instance in the pod could not be queried, see the errors for details.",
+	"Endpoint data missing":   "The pod matches the service selector but is not included in the Endpoints resource, it might not be ready.",
+	"*replica missing*":       "The slot range has no replica, data is lost if the master fails.",
+	"*same host*":             "The master and all replicas of the slot range run on the same K8s host, a host failure loses the range.",
+	"*different slots view*":  "The CLUSTER SLOTS view of the pod differs from the view shared by most pods.",
+	"*uncovered*":             "No master serves the slots, the cluster state fails unless cluster-require-full-coverage is disabled.",
+	"*same zone*":             "The master and all replicas of the slot range run in the same zone, a zone failure loses the range.",
+	"*zone majority*":         "The master runs in a zone holding at least half of the masters, a zone failure leaves no majority for failovers.",
+	remarkLinkDown:            "The replication link between the replica and its master is down.",
+	remarkLagging:             "The replication offset of the replica is behind its master by more than the allowed lag.",
+	remarkResyncing:           "The replica is doing a synchronization with its master.",
+	remarkUnreachable:         "The replication state of the pod is unknown since its Redis instance could not be queried.",
+	remarkNotMember:           "The pod is not known by any other pod, the Redis instance has not joined the cluster.",
+	remarkSplitBrain:          "The master claims slots that are also claimed by another master.",
+	remarkEpochCollision:      "The master uses the same config epoch as another master, which should be resolved by Redis automatically.",
+	remarkPartitioned:         "The pod sees a different set of healthy cluster members than the majority of the pods.",
+	remarkMaxmemoryUnset:      "Redis has no maxmemory, the container is OOM killed instead of Redis evicting keys or rejecting writes.",
+	remarkMaxmemoryAboveLimit: "The maxmemory of Redis is above the container memory limit, the container is OOM killed before maxmemory is reached.",
+	remarkNearMaxmemory:       "The used memory is close to maxmemory, Redis will soon evict keys or reject writes.",
+	remarkBgsaveFailed:        "The last RDB save in the background failed, writes are refused when stop-writes-on-bgsave-error is set.",
+	remarkAOFWriteFailed:      "The last write to the append only file failed.",
+	remarkAOFRewriteFailed:    "The last rewrite of the append only file failed.",
+	remarkNeverPersisted:      "The pod has changes but no append only file and no RDB save since it started, the changes are lost on restart.",
+	remarkNearLimit:           "The resident memory of Redis is close to the container memory limit, the container risks being OOM killed.",
+}
+
+// Explanations of the remarks that includes a pod name, given the start of the remark
+var remarkPrefixExplanations = map[string]string{
+	"*migrating to pod ":   "The master has slots in migrating state, like during a reshard, and clients may get ASK redirects.",
+	"*importing from pod ": "The master has slots in importing state, like during a reshard, and clients may get ASK redirects.",
+}
+
+// remarkExplanation returns the explanation of a remark
+func remarkExplanation(remark string) string {
+	if text, found := remarkExplanations[remark]; found {
+		return text
+	}
+	for prefix, text := range remarkPrefixExplanations {
+		if strings.HasPrefix(remark, prefix) {
+			return text
+		}
+	}
+	return ""
+}
+
+// INFO fields included in the report, per section
+var reportInfoSections = []struct {
+	title  string
+	fields []string
+}{
+	{"Memory", []string{"used_memory_human", "used_memory_peak_human", "maxmemory_human",
+		"maxmemory_policy", "mem_fragmentation_ratio"}},
+	{"Replication", []string{"role", "connected_slaves", "master_link_status",
+		"master_repl_offset", "master_last_io_seconds_ago"}},
+	{"Persistence", []string{"loading", "rdb_changes_since_last_save", "rdb_last_bgsave_status",
+		"rdb_last_save_time", "aof_enabled", "aof_last_bgrewrite_status"}},
+}
+
+type reportCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	format      string
+	file        string
+
+	*clusterState
+}
+
+// A section of the report, with preformatted text or a table
+type reportSection struct {
+	Title   string
+	Text    string
+	Headers []string
+	Rows    [][]string
+}
+
+// NewReportCmd initialize and creates a Cobra command
+func NewReportCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &reportCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report [service-name] [flags]",
+		Short: "Write a health report of a Redis Cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.format, "format", "f", formatHTML, "Report format. One of: html|markdown")
+	cmd.Flags().StringVar(&c.file, "file", "", "Write the report to a file instead of stdout")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *reportCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *reportCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if c.format != formatHTML && c.format != formatMarkdown {
+		return fmt.Errorf("unsupported report format: %s, allowed formats are: %s|%s",
+			c.format, formatHTML, formatMarkdown)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *reportCmd) Run() error {
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, c.streams.ErrOut)
+	if err != nil {
+		return err
+	}
+	query.withZones = true
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	out := c.streams.Out
+	if c.file != "" {
+		f, err := os.Create(c.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	//	Write result
+	title := fmt.Sprintf("Redis Cluster report: %s/%s", c.namespace, c.serviceName)
+	if c.format == formatMarkdown {
+		err = writeMarkdownReport(out, title, c.sections())
+	} else {
+		err = writeHTMLReport(out, title, c.sections())
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.file != "" {
+		fmt.Fprintf(c.streams.ErrOut, "Report written to %s\n", c.file)
+	}
+	return nil
+}
+
+// sections returns all parts of the report
+func (c *reportCmd) sections() []reportSection {
+	sections := []reportSection{
+		{
+			Title: "Generated",
+			Text:  time.Now().Format(time.RFC1123),
+		},
+	}
+
+	slots := &slotsCmd{streams: c.streams, clusterState: c.clusterState}
+	buf := &bytes.Buffer{}
+	slots.outputResult(buf)
+	sections = append(sections, reportSection{Title: "Slots", Text: buf.String()})
+
+	nodes := &nodesCmd{streams: c.streams, output: outputWide, clusterState: c.clusterState}
+	buf = &bytes.Buffer{}
+	nodes.outputResult(buf)
+	sections = append(sections, reportSection{Title: "Nodes", Text: buf.String()})
+
+	sections = append(sections, c.remarksSection())
+	for _, s := range reportInfoSections {
+		sections = append(sections, c.infoSection(s.title, s.fields))
+	}
+	sections = append(sections, c.placementSection())
+	return sections
+}
+
+// remarksSection lists all remarks with an explanation, and where they are found
+func (c *reportCmd) remarksSection() reportSection {
+	found := map[string][]string{}
+	for _, p := range c.podList() {
+		addRemarks(found, p.Name, c.remarks[p.Name])
+		if p.Info != "" {
+			addRemarks(found, p.Name, []string{p.Info})
+		}
+	}
+	zones := c.zoneMajorities()
+	for _, slot := range c.slotsWithGaps() {
+		addRemarks(found, fmt.Sprintf("slots %d-%d", slot.Start, slot.End), c.slotRangeRemarks(slot, zones))
+	}
+	for _, d := range c.slotsViewDiffs() {
+		addRemarks(found, d.Pod, []string{"*different slots view*"})
+	}
+	for _, r := range c.replicationInfos(defaultMaxLag) {
+		addRemarks(found, r.Pod, r.Remarks)
+	}
+	for _, m := range c.memoryInfos(defaultMemoryThreshold) {
+		addRemarks(found, m.Pod, m.Remarks)
+	}
+	for _, p := range c.persistenceInfos() {
+		addRemarks(found, p.Pod, p.Remarks)
+	}
+	return remarksTable("Remarks", found)
+}
+
+// addRemarks adds the remarks of a pod, or a slot range, to the places where each remark is found
+func addRemarks(found map[string][]string, where string, remarks []string) {
+	for _, remark := range remarks {
+		found[remark] = append(found[remark], where)
+	}
+}
+
+// remarksTable returns a section listing the found remarks, ordered by name
+func remarksTable(title string, found map[string][]string) reportSection {
+	remarks := []string{}
+	for remark := range found {
+		remarks = append(remarks, remark)
+	}
+	sort.Strings(remarks)
+
+	section := reportSection{Title: title, Headers: []string{"REMARK", "EXPLANATION", "FOUND IN"}}
+	for _, remark := range remarks {
+		section.Rows = append(section.Rows,
+			[]string{remark, remarkExplanation(remark), strings.Join(found[remark], ", ")})
+	}
+	if len(remarks) == 0 {
+		section.Text = "No remarks."
+	}
+	return section
+}
+
+// infoSection shows given Redis INFO fields per pod
+func (c *reportCmd) infoSection(title string, fields []string) reportSection {
+	section := reportSection{Title: title, Headers: append([]string{"PODNAME"}, fields...)}
+	for _, p := range c.podList() {
+		row := []string{p.Name}
+		for _, field := range fields {
+			row = append(row, c.redisInfo[p.Name][field])
+		}
+		section.Rows = append(section.Rows, row)
+	}
+	return section
+}
+
+// placementSection shows the pods per K8s host
+func (c *reportCmd) placementSection() reportSection {
+	section := reportSection{Title: "K8s placement",
+		Headers: []string{"ZONE", "HOST", "PODNAME", "IP", "ROLE", "RESTARTS", "START-TIME"}}
+	for _, p := range c.podList() {
+		section.Rows = append(section.Rows, []string{p.Zone, p.Host, p.Name, p.IP, c.podRole(p.Name),
+			fmt.Sprintf("%d", p.Restarts), p.StartTime})
+	}
+	return section
+}
+
+func writeMarkdownReport(out io.Writer, title string, sections []reportSection) error {
+	escape := strings.NewReplacer("|", "\\|", "*", "\\*", "\n", " ")
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n", title)
+	for _, s := range sections {
+		fmt.Fprintf(b, "\n## %s\n\n", s.Title)
+		if strings.Contains(s.Text, "\n") {
+			fmt.Fprintf(b, "```\n%s```\n", s.Text)
+		} else if s.Text != "" {
+			fmt.Fprintf(b, "%s\n", s.Text)
+		}
+		if len(s.Rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(s.Headers, " | "))
+		fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", len(s.Headers)))
+		for _, row := range s.Rows {
+			cells := []string{}
+			for _, cell := range row {
+				cells = append(cells, escape.Replace(cell))
+			}
+			fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+const htmlReport = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}
+<h2>{{.Title}}</h2>
+{{if .Text}}<pre>{{.Text}}</pre>{{end}}
+{{if .Rows}}<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+{{end}}
+</body>
+</html>
+`
+
+func writeHTMLReport(out io.Writer, title string, sections []reportSection) error {
+	tmpl, err := template.New("report").Parse(htmlReport)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(out, struct {
+		Title    string
+		Sections []reportSection
+	}{title, sections})
+}
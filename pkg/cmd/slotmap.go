@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Characters used in the slot map
+const (
+	slotmapLetters    = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	slotmapUncovered  = '.'
+	slotmapOpen       = '*'
+	slotmapMixed      = '+'
+	slotmapOverflowed = '?'
+)
+
+// Background colors used per master when coloring the map
+var slotmapColors = []int{41, 42, 43, 44, 45, 46, 101, 102, 103, 104, 105, 106}
+
+type slotmapCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	cellSize    int
+	width       int
+	color       bool
+
+	*clusterState
+}
+
+// NewSlotmapCmd initialize and creates a Cobra command
+func NewSlotmapCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &slotmapCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "slotmap [service-name] [flags]",
+		Short: "Show a map of all slots and their master",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().IntVar(&c.cellSize, "cell-size", 16, "Number of slots per character in the map")
+	cmd.Flags().IntVar(&c.width, "width", 64, "Number of characters per row in the map")
+	cmd.Flags().BoolVar(&c.color, "color", false, "Color the map by master")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *slotmapCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *slotmapCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if c.cellSize < 1 || c.cellSize > redisutils.SlotCount {
+		return fmt.Errorf("the cell size needs to be between 1 and %d, got %d", redisutils.SlotCount, c.cellSize)
+	}
+	if c.width < 1 {
+		return fmt.Errorf("the width needs to be positive, got %d", c.width)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *slotmapCmd) Run() error {
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, c.streams.Out)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	c.outputResult(c.streams.Out)
+
+	return nil
+}
+
+func (c *slotmapCmd) outputResult(out io.Writer) {
+	owners := c.slotOwners()
+	if owners == nil {
+		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any CLUSTER SLOTS data to show..")
+		return
+	}
+	openSlots := map[int]bool{}
+	for _, o := range c.openSlots() {
+		openSlots[o.Slot] = true
+	}
+
+	// Give each master a letter, ordered by first slot
+	letters := map[string]byte{}
+	colors := map[string]int{}
+	masters := []string{}
+	for _, owner := range owners {
+		if _, found := letters[owner]; owner != "" && !found {
+			letter := byte(slotmapOverflowed)
+			if len(masters) < len(slotmapLetters) {
+				letter = slotmapLetters[len(masters)]
+			}
+			letters[owner] = letter
+			colors[owner] = slotmapColors[len(masters)%len(slotmapColors)]
+			masters = append(masters, owner)
+		}
+	}
+
+	fmt.Fprintln(out)
+	cells := (redisutils.SlotCount + c.cellSize - 1) / c.cellSize
+	for cell := 0; cell < cells; cell++ {
+		if cell%c.width == 0 {
+			if cell > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "%5d ", cell*c.cellSize)
+		}
+
+		owner, char := c.cellChar(cell, owners, openSlots, letters)
+		if c.color && owner != "" {
+			fmt.Fprintf(out, "\033[%dm%c\033[0m", colors[owner], char)
+		} else {
+			fmt.Fprintf(out, "%c", char)
+		}
+	}
+	fmt.Fprintln(out)
+
+	// Legend, with slot and range count per master
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "\tMASTER\tHOST\tSLOTS\tRANGES")
+	slotCount, rangeCount := slotsPerOwner(owners)
+	for _, owner := range masters {
+		fmt.Fprintf(w, "%c\t%s\t%s\t%d\t%d\n", letters[owner], owner,
+			c.k8sInfo.GetPodInfoByName(owner).Host, slotCount[owner], rangeCount[owner])
+	}
+	if slotCount[""] > 0 {
+		fmt.Fprintf(w, "%c\t%s\t\t%d\t%d\n", slotmapUncovered, "*uncovered*", slotCount[""], rangeCount[""])
+	}
+	if len(openSlots) > 0 {
+		fmt.Fprintf(w, "%c\t%s\t\t%d\t\n", slotmapOpen,
+			fmt.Sprintf("*migrating/importing* (%s)", formatSlots(openSlots)), len(openSlots))
+	}
+	if c.cellSize > 1 {
+		fmt.Fprintf(w, "%c\t%s\n", slotmapMixed, "*multiple masters*")
+	}
+}
+
+// cellChar returns the master and character representing a cell in the map
+func (c *slotmapCmd) cellChar(cell int, owners []string, openSlots map[int]bool, letters map[string]byte) (string, byte) {
+	start := cell * c.cellSize
+	end := start + c.cellSize
+	if end > len(owners) {
+		end = len(owners)
+	}
+
+	owner := owners[start]
+	mixed := false
+	for slot := start; slot < end; slot++ {
+		if openSlots[slot] {
+			return "", slotmapOpen
+		}
+		if owners[slot] == "" {
+			return "", slotmapUncovered
+		}
+		if owners[slot] != owner {
+			mixed = true
+		}
+	}
+	if mixed {
+		return "", slotmapMixed
+	}
+	return owner, letters[owner]
+}
+
+// slotsPerOwner counts slots and slot ranges per owner, where uncovered slots has an empty owner
+func slotsPerOwner(owners []string) (map[string]int, map[string]int) {
+	slotCount := map[string]int{}
+	rangeCount := map[string]int{}
+	for slot, owner := range owners {
+		slotCount[owner]++
+		if slot == 0 || owners[slot-1] != owner {
+			rangeCount[owner]++
+		}
+	}
+	return slotCount, rangeCount
+}
+
+// formatSlots returns a sorted and comma separated list of slots
+func formatSlots(slots map[int]bool) string {
+	list := []int{}
+	for slot := range slots {
+		list = append(list, slot)
+	}
+	sort.Ints(list)
+
+	result := []string{}
+	for _, slot := range list {
+		result = append(result, strconv.Itoa(slot))
+	}
+	return strings.Join(result, ",")
+}
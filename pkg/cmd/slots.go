@@ -2,41 +2,47 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
-	"github.com/bjosv/kubectl-rediscluster/pkg/portforwarder"
-	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
 	"github.com/go-redis/redis/v8"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
+// Output document of the slots command
+type slotList struct {
+	outputList
+	Pods []podOutput `json:"pods,omitempty"`
+
+	// Pod which CLUSTER SLOTS view is shown, and pods with a different view
+	SlotsView       string          `json:"slotsView,omitempty"`
+	Inconsistencies []slotsViewDiff `json:"inconsistencies,omitempty"`
+	ZoneMajorities  []zoneMajority  `json:"zoneMajorities,omitempty"`
+	OpenSlots       []openSlot      `json:"openSlots,omitempty"`
+}
+
 type slotsCmd struct {
 	configFlags *genericclioptions.ConfigFlags
 	streams     *genericclioptions.IOStreams
 	args        []string
 	verbose     bool
+	output      string
+	filter      podFilter
+	watch       watchFlags
 
-	k8sInfo    *k8s.ClusterInfo
-	redisInfo  map[string]redisutils.RedisInfo
-	redisSlots map[string]redisutils.ClusterSlots
-	remarks    map[string][]string
-	errors     map[string][]string
+	*clusterState
 }
 
 // NewSlotsCmd initialize and creates a Cobra command
 func NewSlotsCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	c := &slotsCmd{
-		configFlags: genericclioptions.NewConfigFlags(true),
-		streams:     &streams,
-		k8sInfo:     k8s.NewClusterInfo(),
-		redisInfo:   make(map[string]redisutils.RedisInfo),
-		redisSlots:  make(map[string]redisutils.ClusterSlots),
-		remarks:     make(map[string][]string),
-		errors:      make(map[string][]string),
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
 	}
 
 	cmd := &cobra.Command{
@@ -61,6 +67,9 @@ func NewSlotsCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	c.configFlags.AddFlags(cmd.Flags())
 
 	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	c.filter.addFlags(cmd.Flags())
+	c.watch.addFlags(cmd.Flags())
 	return cmd
 }
 
@@ -76,90 +85,59 @@ func (c *slotsCmd) Validate() error {
 	if len(c.args) > 1 {
 		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
 	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if err := c.filter.validate(); err != nil {
+		return err
+	}
+	if err := c.watch.validate(c.output); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 // Run the command
 func (c *slotsCmd) Run() error {
-	namespace, err := k8s.CurrentNamespace(c.configFlags)
-	if err != nil {
-		return err
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
 	}
-
-	restConfig, err := c.configFlags.ToRESTConfig()
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
 	if err != nil {
 		return err
 	}
+	query.withZones = true
 
-	serviceName := ""
-	if len(c.args) > 0 {
-		serviceName = c.args[0]
-	} else {
-		serviceName, err = k8s.FindServiceUsingPort(restConfig, namespace, redisutils.RedisPort)
-		if err != nil {
-			return fmt.Errorf("%s\n\nPlease provide a service name", err)
-		}
-		fmt.Fprintf(c.streams.Out, "Using service name: %s\n", serviceName)
+	if c.watch.enabled {
+		return runWatch(query, c.watch.interval, c.streams.Out, func(state *clusterState, w io.Writer) {
+			c.clusterState = state
+			c.outputResult(w)
+		})
 	}
 
-	// Get pod info
-	err = getK8sInfo(restConfig, serviceName, namespace, c.k8sInfo)
+	state, err := query.collect()
 	if err != nil {
 		return err
 	}
-
-	var pfwd *portforwarder.PortForwarder
-	if c.verbose {
-		pfwd = portforwarder.New(restConfig, c.streams.Out, c.streams.ErrOut)
-	} else {
-		pfwd = portforwarder.New(restConfig, nil, nil)
-
-		// Silence K8s errors, like connection refuse
-		logKubeError := func(err error) {}
-		runtime.ErrorHandlers = []func(error){logKubeError}
-	}
-
-	// Query all pods/redis instances
-	ch := make(chan QueryRedisResult)
-	for _, pod := range c.k8sInfo.Pods {
-		go func(podName string, podPort int, ch chan QueryRedisResult) {
-			redisInfo, _, clusterSlots, err := redisutils.QueryRedis(pfwd, namespace, podName, podPort)
-			ch <- QueryRedisResult{
-				PodName: podName,
-				Info:    redisInfo,
-				Slots:   clusterSlots,
-				Error:   err,
-			}
-		}(pod.Name, redisutils.RedisPort, ch)
-	}
-
-	// Collect results from all pods/redis instances
-	for range c.k8sInfo.Pods {
-		queryResult := <-ch
-
-		if queryResult.Error != nil {
-			pod := queryResult.PodName
-			c.remarks[pod] = append(c.remarks[pod], "RedisUnavailable")
-			c.errors[pod] = append(c.errors[pod],
-				fmt.Sprintf("Failed to get Redis information: %s", queryResult.Error))
-		}
-		if queryResult.Info != nil {
-			c.redisInfo[queryResult.PodName] = queryResult.Info
-		}
-		if queryResult.Slots != nil {
-			c.redisSlots[queryResult.PodName] = queryResult.Slots
-		}
-	}
+	c.clusterState = state
 
 	//	Display result
-	c.outputResult()
+	if isMachineOutput(c.output) {
+		return c.printOutput()
+	}
+	c.outputResult(c.streams.Out)
 
 	return nil
 }
 
 func analyzeSlotsInfo(slots redis.ClusterSlot, info *k8s.ClusterInfo) string {
 	result := ""
+	// Check coverage
+	if len(slots.Nodes) == 0 {
+		result += "*uncovered*"
+	}
 	// Check redundancy
 	if len(slots.Nodes) == 1 {
 		result += "*replica missing*"
@@ -180,37 +158,82 @@ func analyzeSlotsInfo(slots redis.ClusterSlot, info *k8s.ClusterInfo) string {
 			result += "*same host*"
 		}
 	}
+	// Check distribution on zones, when the pods are spread over multiple known zones
+	if len(slots.Nodes) > 1 && len(info.Zones()) > 1 && !strings.Contains(result, "*same host*") {
+		zone := ""
+		for i, node := range slots.Nodes {
+			z := info.GetPodInfo(node.Addr).Zone
+			if i == 0 {
+				zone = z
+			} else if z != zone {
+				zone = ""
+				break // Found difference, skip rest
+			}
+		}
+		if zone != "" {
+			result += "*same zone*"
+		}
+	}
 	return result
 }
 
-func (c *slotsCmd) outputResult() {
+func (c *slotsCmd) printOutput() error {
+	slots := c.filter.filterSlots(c.clusterState, c.slotsWithGaps())
+	zones := c.zoneMajorities()
+	list := slotList{
+		outputList:      *c.newOutputList("SlotList", c.slotRangeOutputs(slots, zones)),
+		Pods:            c.podOutputs(c.filter.filterPods(c.clusterState, c.podListByName())),
+		SlotsView:       c.slotsPodName(),
+		Inconsistencies: c.slotsViewDiffs(),
+		ZoneMajorities:  zones,
+		OpenSlots:       c.openSlots(),
+	}
+	return printOutput(list, c.output, c.streams.Out)
+}
+
+func (c *slotsCmd) outputResult(out io.Writer) {
 	if len(c.redisSlots) == 0 {
 		fmt.Fprintln(c.streams.ErrOut, "!! Unable to get any CLUSTER SLOTS data to show..")
 		return
 	}
 
-	w := tabwriter.NewWriter(c.streams.Out, 6, 4, 2, ' ', 0)
+	w := tabwriter.NewWriter(out, 6, 4, 2, ' ', 0)
 	defer w.Flush()
 
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "START\tEND\tROLE\tIP\tPODNAME\tHOST\tREMARKS")
 
-	// Get last podName
-	podName := ""
-	for k := range c.redisSlots {
-		podName = k
+	podName := c.slotsPodName()
+	diffs := c.slotsViewDiffs()
+	differingPods := map[string]bool{}
+	for _, d := range diffs {
+		differingPods[d.Pod] = true
 	}
 
-	for _, slots := range c.redisSlots[podName] {
-		remarksSlots := analyzeSlotsInfo(slots, c.k8sInfo)
+	zones := c.zoneMajorities()
+	for _, slots := range c.filter.filterSlots(c.clusterState, c.slotsWithGaps()) {
+		remarksSlots := strings.Join(c.slotRangeRemarks(slots, zones), ", ")
+
+		if len(slots.Nodes) == 0 {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+				slots.Start, slots.End, "-", "-", "-", "-", remarksSlots)
+			continue
+		}
 
+		firstRow := true
 		for i, node := range slots.Nodes {
-			remarkList := c.remarks[podName]
+			if !c.filter.matchSlotNode(c.clusterState, i, node.Addr) {
+				continue
+			}
 
 			podInfo := c.k8sInfo.GetPodInfo(node.Addr)
+			remarkList := append([]string{}, c.remarks[podInfo.Name]...)
 			if podInfo.Info != "" {
 				remarkList = append(remarkList, podInfo.Info)
 			}
+			if differingPods[podInfo.Name] {
+				remarkList = append(remarkList, "*different slots view*")
+			}
 			if remarksSlots != "" {
 				remarkList = append(remarkList, remarksSlots)
 			}
@@ -223,25 +246,50 @@ func (c *slotsCmd) outputResult() {
 				remarks += info
 			}
 
+			role := "repl"
 			if i == 0 {
+				role = "master"
+			}
+			if firstRow {
 				fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
-					slots.Start, slots.End, "master", node.Addr, podInfo.Name, podInfo.Host, remarks)
+					slots.Start, slots.End, role, node.Addr, podInfo.Name, podInfo.Host, remarks)
+				firstRow = false
 			} else {
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					".", ".", "repl", node.Addr, podInfo.Name, podInfo.Host, remarks)
+					".", ".", role, node.Addr, podInfo.Name, podInfo.Host, remarks)
 			}
 		}
 	}
 
-	// Print errors
-	addNewline := true
-	for name, v := range c.errors {
-		for _, text := range v {
-			if addNewline {
-				fmt.Fprintf(w, "\n")
-				addNewline = false
+	// Print slots in migrating or importing state
+	if open := c.openSlots(); len(open) > 0 {
+		fmt.Fprintf(w, "\nOpen slots:\n")
+		for _, o := range open {
+			fmt.Fprintf(w, "%d:\t%s\n", o.Slot, o.describe())
+		}
+	}
+
+	// Print zones that would leave the masters without majority when lost
+	if len(zones) > 0 {
+		fmt.Fprintf(w, "\nZones holding at least half of the masters, no majority of masters remains if one is lost:\n")
+		for _, z := range zones {
+			fmt.Fprintf(w, "%s:\t%d of %d masters: %s\n",
+				z.Zone, len(z.Masters), z.TotalMasters, strings.Join(z.Masters, ", "))
+		}
+	}
+
+	// Print how pods disagree with the shown view
+	if len(diffs) > 0 {
+		_, shared := c.authoritativeSlotsPod()
+		fmt.Fprintf(w, "\nShowing the view of %s, shared by %d of %d pods. Differing views:\n",
+			podName, shared, len(c.redisSlots))
+		for _, d := range diffs {
+			for _, text := range d.Differences {
+				fmt.Fprintf(w, "%s:\t%s\n", d.Pod, text)
 			}
-			fmt.Fprintf(w, "%s:\t%s\n", name, text)
 		}
 	}
+
+	// Print errors
+	c.outputErrors(w)
 }
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Orders of the slow log timeline
+const (
+	sortByTime     = "time"
+	sortByDuration = "duration"
+)
+
+// Default number of slow log entries fetched per pod, same as the default slowlog-max-len
+const defaultSlowLogCount = 128
+
+type slowlogCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	output      string
+	count       int64
+	sortBy      string
+	group       bool
+	reset       bool
+
+	*clusterState
+}
+
+// A slow log entry and the pod it was fetched from
+type slowLogEntry struct {
+	Time       time.Time `json:"time"`
+	DurationUs int64     `json:"durationUs"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	Client     string    `json:"client,omitempty"`
+	ClientName string    `json:"clientName,omitempty"`
+	ID         int64     `json:"id"`
+	Pod        string    `json:"pod"`
+	Role       string    `json:"role,omitempty"`
+	Host       string    `json:"host"`
+}
+
+// Slow log entries of a command name
+type slowLogGroup struct {
+	Command         string   `json:"command"`
+	Count           int      `json:"count"`
+	TotalDurationUs int64    `json:"totalDurationUs"`
+	MaxDurationUs   int64    `json:"maxDurationUs"`
+	Pods            []string `json:"pods"`
+}
+
+// NewSlowlogCmd initialize and creates a Cobra command
+func NewSlowlogCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &slowlogCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "slowlog [service-name] [flags]",
+		Short: "Show the slow log of all pods in a Redis Cluster as one timeline",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", outputUsage)
+	cmd.Flags().Int64Var(&c.count, "count", defaultSlowLogCount, "Number of latest slow log entries to fetch per pod")
+	cmd.Flags().StringVar(&c.sortBy, "sort-by", sortByTime, "Order of the entries, latest first or longest first. One of: time|duration")
+	cmd.Flags().BoolVar(&c.group, "group", false, "Group the entries by command name")
+	cmd.Flags().BoolVar(&c.reset, "reset", false, "Reset the slow log of all pods after fetching all of its entries")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *slowlogCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *slowlogCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if err := validateOutput(c.output, outputFormats...); err != nil {
+		return err
+	}
+	if c.count <= 0 {
+		return fmt.Errorf("count must be a positive number, got %d", c.count)
+	}
+	if c.sortBy != sortByTime && c.sortBy != sortByDuration {
+		return fmt.Errorf("unsupported sort order: %s, allowed values are: %s|%s", c.sortBy, sortByTime, sortByDuration)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *slowlogCmd) Run() error {
+	infoOut := c.streams.Out
+	if isMachineOutput(c.output) {
+		infoOut = c.streams.ErrOut
+	}
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, infoOut)
+	if err != nil {
+		return err
+	}
+	// Reuse the connections of the collect when running the commands on the pods
+	query.keepConnections = true
+	defer query.close()
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	entries := c.fetchSlowLogs(query)
+	sortSlowLog(entries, c.sortBy)
+
+	//	Display result
+	if isMachineOutput(c.output) {
+		if c.group {
+			return printOutput(c.newOutputList("SlowLogGroupList", groupSlowLog(entries)), c.output, c.streams.Out)
+		}
+		return printOutput(c.newOutputList("SlowLogList", entries), c.output, c.streams.Out)
+	}
+	if c.group {
+		c.outputGroups(c.streams.Out, groupSlowLog(entries))
+	} else {
+		c.outputResult(c.streams.Out, entries)
+	}
+
+	return nil
+}
+
+func (c *slowlogCmd) outputResult(out io.Writer, entries []slowLogEntry) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "TIME\tDURATION\tPODNAME\tROLE\tHOST\tCLIENT\tCOMMAND")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Time.Format("2006-01-02 15:04:05"),
+			time.Duration(e.DurationUs)*time.Microsecond, e.Pod, e.Role, e.Host, e.Client, strings.Join(e.Args, " "))
+	}
+	c.outputErrors(w)
+}
+
+func (c *slowlogCmd) outputGroups(out io.Writer, groups []slowLogGroup) {
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "COMMAND\tCOUNT\tTOTAL\tAVERAGE\tMAX\tPODS")
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", g.Command, g.Count,
+			time.Duration(g.TotalDurationUs)*time.Microsecond,
+			time.Duration(g.TotalDurationUs/int64(g.Count))*time.Microsecond,
+			time.Duration(g.MaxDurationUs)*time.Microsecond, strings.Join(g.Pods, ", "))
+	}
+	c.outputErrors(w)
+}
+
+// fetchSlowLogs fetches the slow log of all pods in parallel. When resetting, all entries
+// are fetched, ignoring the count, since the entries not fetched would be lost.
+func (c *slowlogCmd) fetchSlowLogs(query *clusterQuery) []slowLogEntry {
+	pods := []k8s.PodInfo{}
+	for _, p := range c.podList() {
+		if _, ok := c.redisInfo[p.Name]; ok {
+			pods = append(pods, p)
+		}
+	}
+	results := query.runOnPods(pods, func(pod k8s.PodInfo, conn *redisutils.Connection) (interface{}, error) {
+		if c.reset {
+			return conn.SlowLogGetAndReset()
+		}
+		return conn.SlowLogGet(c.count)
+	})
+
+	result := []slowLogEntry{}
+	for _, r := range results {
+		if r.err != nil {
+			c.errors[r.pod.Name] = append(c.errors[r.pod.Name], fmt.Sprintf("slow log failed: %v", r.err))
+		}
+		entries, _ := r.value.([]redisutils.SlowLogEntry)
+		for _, e := range entries {
+			entry := slowLogEntry{
+				Time:       e.Time,
+				DurationUs: e.Duration.Microseconds(),
+				Args:       e.Args,
+				Client:     e.ClientAddr,
+				ClientName: e.ClientName,
+				ID:         e.ID,
+				Pod:        r.pod.Name,
+				Role:       c.podRole(r.pod.Name),
+				Host:       r.pod.Host,
+			}
+			if len(e.Args) > 0 {
+				entry.Command = strings.ToLower(e.Args[0])
+			}
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// sortSlowLog orders the entries latest first, or longest first
+func sortSlowLog(entries []slowLogEntry, sortBy string) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if sortBy == sortByDuration && entries[i].DurationUs != entries[j].DurationUs {
+			return entries[i].DurationUs > entries[j].DurationUs
+		}
+		return entries[i].Time.After(entries[j].Time)
+	})
+}
+
+// groupSlowLog groups the entries by command name, ordered by the total duration
+func groupSlowLog(entries []slowLogEntry) []slowLogGroup {
+	groups := []slowLogGroup{}
+	index := map[string]int{}
+	for _, e := range entries {
+		i, found := index[e.Command]
+		if !found {
+			i = len(groups)
+			index[e.Command] = i
+			groups = append(groups, slowLogGroup{Command: e.Command, Pods: []string{}})
+		}
+		g := &groups[i]
+		g.Count++
+		g.TotalDurationUs += e.DurationUs
+		if e.DurationUs > g.MaxDurationUs {
+			g.MaxDurationUs = e.DurationUs
+		}
+		if !contains(g.Pods, e.Pod) {
+			g.Pods = append(g.Pods, e.Pod)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].TotalDurationUs > groups[j].TotalDurationUs
+	})
+	return groups
+}
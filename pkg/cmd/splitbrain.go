@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+)
+
+// Remarks added by the CLUSTER NODES analysis
+const (
+	remarkSplitBrain     = "*split brain*"
+	remarkEpochCollision = "*epoch collision*"
+	remarkPartitioned    = "*partitioned*"
+)
+
+// Result from comparing the CLUSTER NODES view of all pods
+type clusterNodesAnalysis struct {
+	SlotConflicts   []slotConflict   `json:"slotConflicts,omitempty"`
+	EpochCollisions []epochCollision `json:"epochCollisions,omitempty"`
+	Partitions      []partition      `json:"partitions,omitempty"`
+}
+
+// Slots claimed by more than one master
+type slotConflict struct {
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+	Pods  []string `json:"pods"`
+}
+
+// Masters using the same config epoch
+type epochCollision struct {
+	ConfigEpoch int64    `json:"configEpoch"`
+	Pods        []string `json:"pods"`
+}
+
+// Pods sharing the same view of which nodes are healthy cluster members
+type partition struct {
+	Pods     []string `json:"pods"`
+	Members  []string `json:"members"`
+	Majority bool     `json:"majority"`
+}
+
+// analyzeClusterNodes compares the CLUSTER NODES view of all pods
+func (s *clusterState) analyzeClusterNodes() clusterNodesAnalysis {
+	return clusterNodesAnalysis{
+		SlotConflicts:   s.findSlotConflicts(),
+		EpochCollisions: s.findEpochCollisions(),
+		Partitions:      s.findPartitions(),
+	}
+}
+
+// addNodesRemarks adds the remarks from the CLUSTER NODES analysis to the involved pods
+func (s *clusterState) addNodesRemarks() {
+	analysis := s.analyzeClusterNodes()
+
+	remarks := map[string]map[string]bool{}
+	add := func(pods []string, remark string) {
+		for _, pod := range pods {
+			if remarks[pod] == nil {
+				remarks[pod] = map[string]bool{}
+			}
+			remarks[pod][remark] = true
+		}
+	}
+	for _, c := range analysis.SlotConflicts {
+		add(c.Pods, remarkSplitBrain)
+	}
+	for _, c := range analysis.EpochCollisions {
+		add(c.Pods, remarkEpochCollision)
+	}
+	for _, p := range analysis.Partitions {
+		if !p.Majority {
+			add(p.Pods, remarkPartitioned)
+		}
+	}
+
+	for _, remark := range []string{remarkSplitBrain, remarkEpochCollision, remarkPartitioned} {
+		for pod := range remarks {
+			if remarks[pod][remark] {
+				s.remarks[pod] = append(s.remarks[pod], remark)
+			}
+		}
+	}
+}
+
+// findSlotConflicts finds slots claimed by more than one master, using each masters own view
+func (s *clusterState) findSlotConflicts() []slotConflict {
+	claims := make([][]string, redisutils.SlotCount)
+	for _, p := range s.podList() {
+		nodes := s.redisNodes[p.Name]
+		self, found := nodes.GetSelf()
+		if !found || !self.HasFlag("master") {
+			continue
+		}
+		for _, r := range self.SlotRanges() {
+			for slot := r[0]; slot <= r[1] && slot < redisutils.SlotCount; slot++ {
+				if slot >= 0 {
+					claims[slot] = append(claims[slot], p.Name)
+				}
+			}
+		}
+	}
+
+	result := []slotConflict{}
+	for slot, pods := range claims {
+		if len(pods) < 2 {
+			continue
+		}
+		// Extend the last conflict when continuous and by the same pods
+		if n := len(result); n > 0 && result[n-1].End == slot-1 &&
+			strings.Join(result[n-1].Pods, ",") == strings.Join(pods, ",") {
+			result[n-1].End = slot
+		} else {
+			result = append(result, slotConflict{Start: slot, End: slot, Pods: pods})
+		}
+	}
+	return result
+}
+
+// findEpochCollisions finds masters that uses the same config epoch, using each masters own view
+func (s *clusterState) findEpochCollisions() []epochCollision {
+	epochs := map[int64][]string{}
+	for _, p := range s.podList() {
+		nodes := s.redisNodes[p.Name]
+		self, found := nodes.GetSelf()
+		if !found || !self.HasFlag("master") {
+			continue
+		}
+		epochs[self.ConfigEpoch] = append(epochs[self.ConfigEpoch], p.Name)
+	}
+
+	result := []epochCollision{}
+	for epoch, pods := range epochs {
+		if len(pods) > 1 {
+			sort.Strings(pods)
+			result = append(result, epochCollision{ConfigEpoch: epoch, Pods: pods})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ConfigEpoch < result[j].ConfigEpoch
+	})
+	return result
+}
+
+// findPartitions groups the pods by their view of which nodes are healthy members.
+// Nodes only flagged as fail? by a pod are still members, since a pod may briefly miss
+// a pong without a partition, while the fail flag is agreed by a majority of the masters.
+// Returns nothing when all pods share the same view.
+func (s *clusterState) findPartitions() []partition {
+	groups := map[string]*partition{}
+	keys := []string{}
+	for _, p := range s.podList() {
+		nodes, ok := s.redisNodes[p.Name]
+		if !ok {
+			continue
+		}
+		members := []string{}
+		for _, n := range nodes.List() {
+			if n.HasFlag("fail") || n.HasFlag("handshake") || n.HasFlag("noaddr") {
+				continue
+			}
+			members = append(members, s.addrName(nodeAddr(n.Addr)))
+		}
+		sort.Strings(members)
+
+		key := strings.Join(members, ",")
+		if _, found := groups[key]; !found {
+			groups[key] = &partition{Members: members}
+			keys = append(keys, key)
+		}
+		groups[key].Pods = append(groups[key].Pods, p.Name)
+	}
+	if len(groups) < 2 {
+		return []partition{}
+	}
+
+	result := []partition{}
+	for _, key := range keys {
+		sort.Strings(groups[key].Pods)
+		result = append(result, *groups[key])
+	}
+	// Largest group first, the lowest pod name wins a tie
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i].Pods) != len(result[j].Pods) {
+			return len(result[i].Pods) > len(result[j].Pods)
+		}
+		return result[i].Pods[0] < result[j].Pods[0]
+	})
+	result[0].Majority = true
+	return result
+}
+
+// nodeAddr removes the cluster bus port from a CLUSTER NODES address
+func nodeAddr(addr string) string {
+	return strings.Split(addr, "@")[0]
+}
+
+// findings returns a description of each finding
+func (a *clusterNodesAnalysis) findings() []string {
+	result := []string{}
+	for _, c := range a.SlotConflicts {
+		result = append(result, fmt.Sprintf("split brain: slots %s claimed by %s",
+			formatSlotRange(c.Start, c.End), strings.Join(c.Pods, ", ")))
+	}
+	for _, c := range a.EpochCollisions {
+		result = append(result, fmt.Sprintf("config epoch collision: epoch %d used by %s",
+			c.ConfigEpoch, strings.Join(c.Pods, ", ")))
+	}
+	for _, p := range a.Partitions {
+		group := "partition"
+		if p.Majority {
+			group = "majority"
+		}
+		result = append(result, fmt.Sprintf("%s: %s sees members %s",
+			group, strings.Join(p.Pods, ", "), strings.Join(p.Members, ", ")))
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindPartitions(t *testing.T) {
+	// flagNode sets the flags of a node in the view of a pod
+	flagNode := func(s *clusterState, podName string, id string, flags string) {
+		fields := s.redisNodes[podName][id]
+		fields[2] = strings.Replace(fields[2], "master", flags, 1)
+		fields[2] = strings.Replace(fields[2], "slave", flags, 1)
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *clusterState)
+		want   []partition
+	}{
+		{
+			name:   "same views",
+			change: func(s *clusterState) {},
+			want:   []partition{},
+		},
+		{
+			name: "node possibly failing in one view",
+			change: func(s *clusterState) {
+				flagNode(s, "rediscluster-cluster-7tpnv", idReplica2, "slave,fail?")
+			},
+			want: []partition{},
+		},
+		{
+			name: "node failed in one view",
+			change: func(s *clusterState) {
+				flagNode(s, "rediscluster-cluster-7tpnv", idReplica2, "slave,fail")
+			},
+			want: []partition{
+				{
+					Pods: []string{"rediscluster-cluster-dqrzl", "rediscluster-cluster-lsx6p",
+						"rediscluster-cluster-m2b7n", "rediscluster-cluster-qfjz4", "rediscluster-cluster-xw8hn"},
+					Members: []string{"rediscluster-cluster-7tpnv", "rediscluster-cluster-dqrzl", "rediscluster-cluster-lsx6p",
+						"rediscluster-cluster-m2b7n", "rediscluster-cluster-qfjz4", "rediscluster-cluster-xw8hn"},
+					Majority: true,
+				},
+				{
+					Pods: []string{"rediscluster-cluster-7tpnv"},
+					Members: []string{"rediscluster-cluster-7tpnv", "rediscluster-cluster-dqrzl", "rediscluster-cluster-lsx6p",
+						"rediscluster-cluster-m2b7n", "rediscluster-cluster-xw8hn"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestState()
+			tt.change(s)
+			if got := s.findPartitions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// Node IDs of the captured cluster, three masters with one replica each
+const (
+	idMaster1  = "07c37dfeb235213a872192d90877d0cd55635b91"
+	idMaster2  = "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1"
+	idMaster3  = "292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f"
+	idReplica1 = "6ec23923021cf3ffec47632106199cb7f496ce01"
+	idReplica2 = "824fe116063bc5fcf9f4ffd895bc17aee7731ac3"
+	idReplica3 = "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca"
+)
+
+// The pods of the captured cluster, each replica on another host than its master
+var testPods = []k8s.PodInfo{
+	{Name: "rediscluster-cluster-7tpnv", IP: "10.244.1.5", Host: "kind-worker", Zone: "zone-a"},
+	{Name: "rediscluster-cluster-dqrzl", IP: "10.244.2.5", Host: "kind-worker2", Zone: "zone-b"},
+	{Name: "rediscluster-cluster-lsx6p", IP: "10.244.3.5", Host: "kind-worker3", Zone: "zone-c"},
+	{Name: "rediscluster-cluster-m2b7n", IP: "10.244.2.6", Host: "kind-worker2", Zone: "zone-b"},
+	{Name: "rediscluster-cluster-qfjz4", IP: "10.244.3.6", Host: "kind-worker3", Zone: "zone-c"},
+	{Name: "rediscluster-cluster-xw8hn", IP: "10.244.1.6", Host: "kind-worker", Zone: "zone-a"},
+}
+
+// Captured CLUSTER NODES reply, without the myself flag
+var testClusterNodes = []string{
+	idMaster1 + " 10.244.1.5:6379@16379 master - 0 1602151311000 1 connected 0-5460",
+	idMaster2 + " 10.244.2.5:6379@16379 master - 0 1602151312000 2 connected 5461-10922",
+	idMaster3 + " 10.244.3.5:6379@16379 master - 0 1602151310000 3 connected 10923-16383",
+	idReplica1 + " 10.244.2.6:6379@16379 slave " + idMaster1 + " 0 1602151312010 1 connected",
+	idReplica2 + " 10.244.3.6:6379@16379 slave " + idMaster2 + " 0 1602151311005 2 connected",
+	idReplica3 + " 10.244.1.6:6379@16379 slave " + idMaster3 + " 0 1602151313000 3 connected",
+}
+
+// Captured CLUSTER INFO and INFO replies, shortened to the fields used
+const testInfo = "cluster_state:ok\r\n" +
+	"cluster_slots_assigned:16384\r\n" +
+	"cluster_known_nodes:6\r\n" +
+	"cluster_size:3\r\n" +
+	"# Server\r\n" +
+	"redis_version:6.0.8\r\n" +
+	"# Memory\r\n" +
+	"used_memory:2630544\r\n" +
+	"used_memory_human:2.51M\r\n"
+
+// testClusterSlots returns the captured CLUSTER SLOTS reply
+func testClusterSlots() redisutils.ClusterSlots {
+	return redisutils.ClusterSlots{
+		{Start: 0, End: 5460, Nodes: []redis.ClusterNode{
+			{ID: idMaster1, Addr: "10.244.1.5:6379"},
+			{ID: idReplica1, Addr: "10.244.2.6:6379"},
+		}},
+		{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{
+			{ID: idMaster2, Addr: "10.244.2.5:6379"},
+			{ID: idReplica2, Addr: "10.244.3.6:6379"},
+		}},
+		{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{
+			{ID: idMaster3, Addr: "10.244.3.5:6379"},
+			{ID: idReplica3, Addr: "10.244.1.6:6379"},
+		}},
+	}
+}
+
+// clusterNodesReply returns the CLUSTER NODES reply given by the instance with the given IP
+func clusterNodesReply(lines []string, ip string) redisutils.ClusterNodes {
+	reply := []string{}
+	for _, line := range lines {
+		fields := strings.Split(line, " ")
+		if strings.HasPrefix(fields[1], ip+":") {
+			fields[2] = "myself," + fields[2]
+		}
+		reply = append(reply, strings.Join(fields, " "))
+	}
+	return redisutils.NewClusterNodes(strings.Join(reply, "\n") + "\n")
+}
+
+// parseInfo parses an INFO reply like the query does
+func parseInfo(reply string) redisutils.RedisInfo {
+	info := redisutils.RedisInfo{}
+	for _, line := range strings.Split(reply, "\r\n") {
+		keyVals := strings.Split(line, ":")
+		if len(keyVals) > 1 {
+			info[keyVals[0]] = keyVals[1]
+		}
+	}
+	return info
+}
+
+// newTestState returns the state collected from the captured cluster
+func newTestState() *clusterState {
+	s := newClusterState()
+	for _, p := range testPods {
+		s.k8sInfo.Pods[p.IP] = p
+		s.addQueryResult(QueryRedisResult{
+			PodName: p.Name,
+			Info:    parseInfo(testInfo),
+			Nodes:   clusterNodesReply(testClusterNodes, p.IP),
+			Slots:   testClusterSlots(),
+		})
+	}
+	return s
+}
+
+// setClusterNodes replaces the CLUSTER NODES reply of all pods
+func (s *clusterState) setClusterNodes(lines []string) {
+	for _, p := range s.k8sInfo.Pods {
+		s.redisNodes[p.Name] = clusterNodesReply(lines, p.IP)
+	}
+}
+
+// setClusterSlots replaces the CLUSTER SLOTS reply of all pods
+func (s *clusterState) setClusterSlots(slots redisutils.ClusterSlots) {
+	for _, p := range s.k8sInfo.Pods {
+		s.redisSlots[p.Name] = append(redisutils.ClusterSlots{}, slots...)
+	}
+	s.slotsView = nil
+}
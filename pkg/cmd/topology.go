@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// Supported graph formats
+const (
+	formatDOT     = "dot"
+	formatMermaid = "mermaid"
+)
+
+type topologyCmd struct {
+	configFlags *genericclioptions.ConfigFlags
+	streams     *genericclioptions.IOStreams
+	args        []string
+	verbose     bool
+	format      string
+
+	*clusterState
+}
+
+// A pod in the topology graph
+type topologyNode struct {
+	id     string
+	pod    string
+	label  []string
+	master string
+	failed bool
+}
+
+// NewTopologyCmd initialize and creates a Cobra command
+func NewTopologyCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	c := &topologyCmd{
+		configFlags:  genericclioptions.NewConfigFlags(true),
+		streams:      &streams,
+		clusterState: newClusterState(),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "topology [service-name] [flags]",
+		Short: "Export the topology of a Redis Cluster as a graph",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := c.Validate(); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true // No usage if Run() fails, like missing service
+			if err := c.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	// Add kubectl config flags to this command
+	c.configFlags.AddFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&c.verbose, "verbose", "v", false, "Show verbose logs")
+	cmd.Flags().StringVarP(&c.format, "format", "f", formatDOT, "Graph format. One of: dot|mermaid")
+	return cmd
+}
+
+// Complete sets all information required for the command
+func (c *topologyCmd) Complete(cmd *cobra.Command, args []string) error {
+	c.args = args
+
+	return nil
+}
+
+// Validate ensures that all required arguments and flag values are provided
+func (c *topologyCmd) Validate() error {
+	if len(c.args) > 1 {
+		return fmt.Errorf("maximum 1 service name can be given, got %d", len(c.args))
+	}
+	if c.format != formatDOT && c.format != formatMermaid {
+		return fmt.Errorf("unsupported graph format: %s, allowed formats are: %s|%s",
+			c.format, formatDOT, formatMermaid)
+	}
+
+	return nil
+}
+
+// Run the command
+func (c *topologyCmd) Run() error {
+	// The graph is expected to be piped, so inform on stderr
+	query, err := newClusterQuery(c.configFlags, c.streams, c.args, c.verbose, c.streams.ErrOut)
+	if err != nil {
+		return err
+	}
+
+	state, err := query.collect()
+	if err != nil {
+		return err
+	}
+	c.clusterState = state
+
+	//	Display result
+	if c.format == formatMermaid {
+		c.outputMermaid(c.streams.Out)
+	} else {
+		c.outputDOT(c.streams.Out)
+	}
+
+	return nil
+}
+
+// graphNodes returns the pods grouped by K8s host, with hosts and pods in the default order
+func (c *topologyCmd) graphNodes() ([]string, map[string][]topologyNode) {
+	hosts := []string{}
+	nodes := map[string][]topologyNode{}
+	for i, p := range c.podList() {
+		if _, found := nodes[p.Host]; !found {
+			hosts = append(hosts, p.Host)
+		}
+
+		n := topologyNode{
+			id:     fmt.Sprintf("n%d", i),
+			pod:    p.Name,
+			label:  []string{p.Name},
+			master: c.masterPodName(p.Name),
+		}
+		if _, failed := c.errors[p.Name]; failed {
+			n.failed = true
+			n.label = append(n.label, "unavailable")
+		} else if role := c.podRole(p.Name); role != "" {
+			n.label = append(n.label, role)
+		}
+		if slots, ok := c.redisSlots[p.Name]; ok {
+			count, _ := slotsCount(p.IP, slots)
+			n.label = append(n.label, fmt.Sprintf("%d slots", count))
+		}
+		nodes[p.Host] = append(nodes[p.Host], n)
+	}
+	return hosts, nodes
+}
+
+// graphEdges returns edges from replica to master, using the graph node IDs
+func graphEdges(hosts []string, nodes map[string][]topologyNode) [][2]string {
+	ids := map[string]string{}
+	for _, host := range hosts {
+		for _, n := range nodes[host] {
+			ids[n.pod] = n.id
+		}
+	}
+
+	edges := [][2]string{}
+	for _, host := range hosts {
+		for _, n := range nodes[host] {
+			if master, found := ids[n.master]; found {
+				edges = append(edges, [2]string{n.id, master})
+			}
+		}
+	}
+	return edges
+}
+
+func (c *topologyCmd) outputDOT(out io.Writer) {
+	hosts, nodes := c.graphNodes()
+
+	fmt.Fprintln(out, "digraph rediscluster {")
+	fmt.Fprintf(out, "  label=%q;\n", c.namespace+"/"+c.serviceName)
+	fmt.Fprintln(out, "  node [shape=box];")
+	for i, host := range hosts {
+		fmt.Fprintf(out, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(out, "    label=%q;\n", host)
+		for _, n := range nodes[host] {
+			style := ""
+			if n.failed {
+				style = ", style=dashed"
+			}
+			fmt.Fprintf(out, "    %s [label=%q%s];\n", n.id, strings.Join(n.label, "\n"), style)
+		}
+		fmt.Fprintln(out, "  }")
+	}
+	for _, edge := range graphEdges(hosts, nodes) {
+		fmt.Fprintf(out, "  %s -> %s;\n", edge[0], edge[1])
+	}
+	fmt.Fprintln(out, "}")
+}
+
+func (c *topologyCmd) outputMermaid(out io.Writer) {
+	hosts, nodes := c.graphNodes()
+
+	fmt.Fprintln(out, "graph BT")
+	for i, host := range hosts {
+		fmt.Fprintf(out, "  subgraph h%d[\"%s\"]\n", i, host)
+		for _, n := range nodes[host] {
+			fmt.Fprintf(out, "    %s[\"%s\"]\n", n.id, strings.Join(n.label, "<br/>"))
+		}
+		fmt.Fprintln(out, "  end")
+	}
+	for _, edge := range graphEdges(hosts, nodes) {
+		fmt.Fprintf(out, "  %s --> %s\n", edge[0], edge[1])
+	}
+	for _, host := range hosts {
+		for _, n := range nodes[host] {
+			if n.failed {
+				fmt.Fprintf(out, "  style %s stroke-dasharray: 5 5\n", n.id)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Number of detected changes shown below the result
+const maxWatchEvents = 10
+
+// Terminal escape sequences
+const (
+	clearScreen    = "\033[H\033[2J"
+	highlightStart = "\033[7m"
+	highlightEnd   = "\033[0m"
+)
+
+// watchFlags enables a repeated query and presentation of the result
+type watchFlags struct {
+	enabled  bool
+	interval time.Duration
+}
+
+func (f *watchFlags) addFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&f.enabled, "watch", "w", false, "Watch for changes, keeps the connections open between refreshes")
+	flags.DurationVar(&f.interval, "interval", 2*time.Second, "Time between refreshes when watching")
+}
+
+func (f *watchFlags) validate(output string) error {
+	if !f.enabled {
+		return nil
+	}
+	if isMachineOutput(output) {
+		return fmt.Errorf("watch is not supported with output format %s", output)
+	}
+	if f.interval <= 0 {
+		return fmt.Errorf("the interval needs to be positive, got %s", f.interval)
+	}
+	return nil
+}
+
+// watchEvent is a detected change between two refreshes
+type watchEvent struct {
+	time time.Time
+	pods []string
+	text string
+}
+
+// runWatch collects and renders the cluster state until interrupted.
+// Rows of pods involved in a change since the last refresh are highlighted.
+func runWatch(query *clusterQuery, interval time.Duration, out io.Writer, render func(*clusterState, io.Writer)) error {
+	query.keepConnections = true
+	defer query.close()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev *clusterState
+	events := []watchEvent{}
+	for {
+		now := time.Now()
+		highlight := map[string]bool{}
+
+		buf := &bytes.Buffer{}
+		state, err := query.collect()
+		if err != nil {
+			fmt.Fprintf(buf, "!! %s\n", err)
+		} else {
+			if prev != nil {
+				for _, e := range detectChanges(prev, state) {
+					e.time = now
+					events = append(events, e)
+					for _, pod := range e.pods {
+						highlight[pod] = true
+					}
+				}
+			}
+			render(state, buf)
+			prev = state
+		}
+		if len(events) > maxWatchEvents {
+			events = events[len(events)-maxWatchEvents:]
+		}
+
+		fmt.Fprint(out, clearScreen)
+		fmt.Fprintf(out, "Every %s: %s/%s\t%s\n",
+			interval, query.namespace, query.serviceName, now.Format(time.RFC1123))
+		writeHighlighted(out, buf, highlight)
+
+		if len(events) > 0 {
+			fmt.Fprintln(out, "\nCHANGES")
+			for _, e := range events {
+				fmt.Fprintf(out, "%s  %s\n", e.time.Format("15:04:05"), e.text)
+			}
+		}
+
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeHighlighted writes each line, highlighted when it mentions a changed pod
+func writeHighlighted(out io.Writer, buf *bytes.Buffer, highlight map[string]bool) {
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		changed := false
+		for _, field := range strings.Fields(line) {
+			if highlight[strings.TrimSuffix(field, ":")] {
+				changed = true
+				break
+			}
+		}
+		if changed {
+			fmt.Fprintf(out, "%s%s%s\n", highlightStart, line, highlightEnd)
+		} else {
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+// detectChanges compares two results and returns the semantic changes
+func detectChanges(prev *clusterState, cur *clusterState) []watchEvent {
+	events := []watchEvent{}
+
+	prevPods := map[string]string{}
+	for _, p := range prev.k8sInfo.Pods {
+		prevPods[p.Name] = p.IP
+	}
+	curPods := map[string]string{}
+	for _, p := range cur.k8sInfo.Pods {
+		curPods[p.Name] = p.IP
+	}
+
+	// Pods appearing, disappearing or changing IP
+	for _, name := range sortedKeys(curPods) {
+		ip, found := prevPods[name]
+		if !found {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s appeared with IP %s", name, curPods[name])})
+		} else if ip != curPods[name] {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s changed IP from %s to %s", name, ip, curPods[name])})
+		}
+	}
+	for _, name := range sortedKeys(prevPods) {
+		if _, found := curPods[name]; !found {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s disappeared", name)})
+		}
+	}
+
+	// Changes within the Redis instances
+	for _, name := range sortedKeys(curPods) {
+		if _, found := prevPods[name]; !found {
+			continue
+		}
+
+		_, prevErr := prev.errors[name]
+		_, curErr := cur.errors[name]
+		if !prevErr && curErr {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s became unreachable", name)})
+		} else if prevErr && !curErr {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s became reachable", name)})
+		}
+
+		prevRole, curRole := prev.podRole(name), cur.podRole(name)
+		if prevRole != "" && curRole != "" && prevRole != curRole {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s changed role from %s to %s", name, prevRole, curRole)})
+		}
+
+		prevState := prev.redisInfo[name]["cluster_state"]
+		curState := cur.redisInfo[name]["cluster_state"]
+		if prevState != "" && curState != "" && prevState != curState {
+			events = append(events, watchEvent{pods: []string{name},
+				text: fmt.Sprintf("pod %s changed cluster_state from %s to %s", name, prevState, curState)})
+		}
+	}
+
+	return append(events, detectSlotMoves(prev, cur)...)
+}
+
+// detectSlotMoves finds slot ranges that changed master
+func detectSlotMoves(prev *clusterState, cur *clusterState) []watchEvent {
+	events := []watchEvent{}
+	prevOwners := prev.slotOwners()
+	curOwners := cur.slotOwners()
+	if prevOwners == nil || curOwners == nil {
+		return events
+	}
+
+	start := -1
+	for slot := 0; slot <= len(curOwners); slot++ {
+		// Close the current range when the move differs, or at the end
+		if start >= 0 && (slot == len(curOwners) ||
+			prevOwners[slot] != prevOwners[start] || curOwners[slot] != curOwners[start]) {
+			from, to := prevOwners[start], curOwners[start]
+			text := ""
+			switch {
+			case from == "":
+				text = fmt.Sprintf("slots %d-%d now served by %s", start, slot-1, to)
+			case to == "":
+				text = fmt.Sprintf("slots %d-%d no longer served, was %s", start, slot-1, from)
+			default:
+				text = fmt.Sprintf("slots %d-%d moved from %s to %s", start, slot-1, from, to)
+			}
+			events = append(events, watchEvent{pods: []string{from, to}, text: text})
+			start = -1
+		}
+		if slot < len(curOwners) && start < 0 && prevOwners[slot] != curOwners[slot] {
+			start = slot
+		}
+	}
+	return events
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
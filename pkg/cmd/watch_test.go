@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bjosv/kubectl-rediscluster/pkg/k8s"
+	"github.com/bjosv/kubectl-rediscluster/pkg/redisutils"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestDetectChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *clusterState)
+		want   []string
+	}{
+		{
+			name:   "no changes",
+			change: func(s *clusterState) {},
+			want:   []string{},
+		},
+		{
+			name: "pod replaced",
+			change: func(s *clusterState) {
+				delete(s.k8sInfo.Pods, "10.244.1.6")
+				s.k8sInfo.Pods["10.244.1.7"] = testPodInfo("rediscluster-cluster-z5k2w", "10.244.1.7")
+			},
+			want: []string{
+				"pod rediscluster-cluster-z5k2w appeared with IP 10.244.1.7",
+				"pod rediscluster-cluster-xw8hn disappeared",
+			},
+		},
+		{
+			name: "pod restarted with a new IP",
+			change: func(s *clusterState) {
+				delete(s.k8sInfo.Pods, "10.244.1.6")
+				s.k8sInfo.Pods["10.244.1.7"] = testPodInfo("rediscluster-cluster-xw8hn", "10.244.1.7")
+			},
+			want: []string{"pod rediscluster-cluster-xw8hn changed IP from 10.244.1.6 to 10.244.1.7"},
+		},
+		{
+			name: "pod unreachable",
+			change: func(s *clusterState) {
+				s.addQueryResult(QueryRedisResult{
+					PodName: "rediscluster-cluster-qfjz4",
+					Error:   errors.New("dial tcp [::1]:45367: connect: connection refused"),
+				})
+			},
+			want: []string{"pod rediscluster-cluster-qfjz4 became unreachable"},
+		},
+		{
+			name: "cluster state failed",
+			change: func(s *clusterState) {
+				s.redisInfo["rediscluster-cluster-lsx6p"]["cluster_state"] = "fail"
+			},
+			want: []string{"pod rediscluster-cluster-lsx6p changed cluster_state from ok to fail"},
+		},
+		{
+			name: "failover",
+			change: func(s *clusterState) {
+				nodes := append([]string{}, testClusterNodes...)
+				nodes[0] = idMaster1 + " 10.244.1.5:6379@16379 slave " + idReplica1 + " 0 1602151411000 7 connected"
+				nodes[3] = idReplica1 + " 10.244.2.6:6379@16379 master - 0 1602151412010 7 connected 0-5460"
+				s.setClusterNodes(nodes)
+
+				slots := testClusterSlots()
+				slots[0].Nodes[0], slots[0].Nodes[1] = slots[0].Nodes[1], slots[0].Nodes[0]
+				s.setClusterSlots(slots)
+			},
+			want: []string{
+				"pod rediscluster-cluster-7tpnv changed role from master to replica",
+				"pod rediscluster-cluster-m2b7n changed role from replica to master",
+				"slots 0-5460 moved from rediscluster-cluster-7tpnv to rediscluster-cluster-m2b7n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, cur := newTestState(), newTestState()
+			tt.change(cur)
+
+			got := []string{}
+			for _, event := range detectChanges(prev, cur) {
+				got = append(got, event.text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSlotMoves(t *testing.T) {
+	master1 := redis.ClusterNode{ID: idMaster1, Addr: "10.244.1.5:6379"}
+	master2 := redis.ClusterNode{ID: idMaster2, Addr: "10.244.2.5:6379"}
+	master3 := redis.ClusterNode{ID: idMaster3, Addr: "10.244.3.5:6379"}
+	unknown := redis.ClusterNode{ID: "f4a6b4ed4d1c5f2a4b21b0a4b2a4bdf49b5f0b06", Addr: "10.244.4.5:6379"}
+
+	tests := []struct {
+		name      string
+		prevSlots redisutils.ClusterSlots
+		slots     redisutils.ClusterSlots
+		want      []watchEvent
+	}{
+		{
+			name:  "no moves",
+			slots: testClusterSlots(),
+			want:  []watchEvent{},
+		},
+		{
+			name:      "slots served again",
+			prevSlots: testClusterSlots()[:2],
+			slots:     testClusterSlots(),
+			want: []watchEvent{
+				{pods: []string{"", "rediscluster-cluster-lsx6p"},
+					text: "slots 10923-16383 now served by rediscluster-cluster-lsx6p"},
+			},
+		},
+		{
+			name: "resharded slots",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 99, Nodes: []redis.ClusterNode{master1}},
+				{Start: 100, End: 199, Nodes: []redis.ClusterNode{master2}},
+				{Start: 200, End: 5460, Nodes: []redis.ClusterNode{master1}},
+				{Start: 5461, End: 10922, Nodes: []redis.ClusterNode{master2}},
+				{Start: 10923, End: 10923, Nodes: []redis.ClusterNode{master2}},
+				{Start: 10924, End: 16383, Nodes: []redis.ClusterNode{master3}},
+			},
+			want: []watchEvent{
+				{pods: []string{"rediscluster-cluster-7tpnv", "rediscluster-cluster-dqrzl"},
+					text: "slots 100-199 moved from rediscluster-cluster-7tpnv to rediscluster-cluster-dqrzl"},
+				{pods: []string{"rediscluster-cluster-lsx6p", "rediscluster-cluster-dqrzl"},
+					text: "slots 10923-10923 moved from rediscluster-cluster-lsx6p to rediscluster-cluster-dqrzl"},
+			},
+		},
+		{
+			name: "slots lost and moved to an unknown pod",
+			slots: redisutils.ClusterSlots{
+				{Start: 0, End: 5460, Nodes: []redis.ClusterNode{master1}},
+				{Start: 10923, End: 16383, Nodes: []redis.ClusterNode{unknown}},
+			},
+			want: []watchEvent{
+				{pods: []string{"rediscluster-cluster-dqrzl", ""},
+					text: "slots 5461-10922 no longer served, was rediscluster-cluster-dqrzl"},
+				{pods: []string{"rediscluster-cluster-lsx6p", "10.244.4.5:6379"},
+					text: "slots 10923-16383 moved from rediscluster-cluster-lsx6p to 10.244.4.5:6379"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, cur := newTestState(), newTestState()
+			if tt.prevSlots != nil {
+				prev.setClusterSlots(tt.prevSlots)
+			}
+			cur.setClusterSlots(tt.slots)
+
+			got := detectSlotMoves(prev, cur)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testPodInfo returns the info of a pod started on the first host
+func testPodInfo(name string, ip string) k8s.PodInfo {
+	return k8s.PodInfo{Name: name, IP: ip, Host: "kind-worker", Zone: "zone-a"}
+}
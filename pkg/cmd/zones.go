@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"sort"
+)
+
+// A zone holding so many masters that the remaining masters are not a majority when the zone is lost
+type zoneMajority struct {
+	Zone         string   `json:"zone"`
+	Masters      []string `json:"masters"`
+	TotalMasters int      `json:"totalMasters"`
+}
+
+// zoneMajorities finds the zones that holds at least half of the masters serving slots,
+// in the shown CLUSTER SLOTS view. A failover requires a majority of these masters,
+// so losing such a zone stops the cluster. Nothing is returned when the zones are unknown,
+// or when all pods are in a single zone.
+func (s *clusterState) zoneMajorities() []zoneMajority {
+	if len(s.k8sInfo.Zones()) < 2 {
+		return []zoneMajority{}
+	}
+
+	masters := map[string]bool{}
+	zones := map[string][]string{}
+	for _, slot := range s.redisSlots[s.slotsPodName()] {
+		if len(slot.Nodes) == 0 || masters[slot.Nodes[0].Addr] {
+			continue
+		}
+		masters[slot.Nodes[0].Addr] = true
+
+		podInfo := s.k8sInfo.GetPodInfo(slot.Nodes[0].Addr)
+		if podInfo.Zone == "" {
+			return []zoneMajority{}
+		}
+		zones[podInfo.Zone] = append(zones[podInfo.Zone], s.addrName(slot.Nodes[0].Addr))
+	}
+
+	result := []zoneMajority{}
+	for zone, pods := range zones {
+		if len(masters) > 1 && len(pods)*2 >= len(masters) {
+			sort.Strings(pods)
+			result = append(result, zoneMajority{Zone: zone, Masters: pods, TotalMasters: len(masters)})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Zone < result[j].Zone
+	})
+	return result
+}
@@ -3,22 +3,40 @@ package k8s
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
 )
 
+// Well-known K8s Node labels for the failure domains, and the deprecated labels used by older clusters
+const (
+	ZoneLabel       = "topology.kubernetes.io/zone"
+	RegionLabel     = "topology.kubernetes.io/region"
+	ZoneLabelBeta   = "failure-domain.beta.kubernetes.io/zone"
+	RegionLabelBeta = "failure-domain.beta.kubernetes.io/region"
+)
+
 type PodInfo struct {
 	Name      string
 	IP        string
 	Host      string
+	Zone      string
+	Region    string
 	Restarts  int
 	StartTime string
 	Info      string
+
+	// Memory request and limit of the Redis container in bytes, or 0 when not set
+	MemoryRequest int64
+	MemoryLimit   int64
 }
 
 type ClusterInfo struct {
 	Pods map[string]PodInfo
+
+	// Set when the K8s nodes could not be listed, the zones of the pods are then unknown
+	ZonesUnknown bool
 }
 
 func NewClusterInfo() *ClusterInfo {
@@ -36,6 +54,16 @@ func (c *ClusterInfo) GetPodInfo(podAddress string) PodInfo {
 	return c.Pods[podAddress]
 }
 
+// GetPodInfoByName returns the pod info given a pod name
+func (c *ClusterInfo) GetPodInfoByName(podName string) PodInfo {
+	for _, p := range c.Pods {
+		if p.Name == podName {
+			return p
+		}
+	}
+	return PodInfo{}
+}
+
 // TODO: handle merger of pod info
 func (c *ClusterInfo) AddPodEndpoints(endpoints *v1.Endpoints) {
 	for _, eps := range endpoints.Subsets {
@@ -59,7 +87,8 @@ func (c *ClusterInfo) AddPodEndpoints(endpoints *v1.Endpoints) {
 	}
 }
 
-func (c *ClusterInfo) UpdatePods(podList *v1.PodList) {
+// UpdatePods adds the pods, with the memory resources of the container exposing given Redis port
+func (c *ClusterInfo) UpdatePods(podList *v1.PodList, redisPort int32) {
 	//fmt.Printf("PodList => %+v\n", podList)
 	for _, pod := range podList.Items {
 		ip := pod.Status.PodIP
@@ -71,6 +100,7 @@ func (c *ClusterInfo) UpdatePods(podList *v1.PodList) {
 				//fmt.Printf("  Container => %+v\n", container.Name)
 				p.Restarts = int(container.RestartCount)
 			}
+			p.MemoryRequest, p.MemoryLimit = redisContainerMemory(&pod, redisPort)
 			c.Pods[ip] = p
 			//fmt.Printf("> Pod updated: %s\n", p.Name)
 		} else {
@@ -81,9 +111,66 @@ func (c *ClusterInfo) UpdatePods(podList *v1.PodList) {
 				Host: pod.Spec.NodeName,
 				Info: "Endpoint data missing",
 			}
+			p.MemoryRequest, p.MemoryLimit = redisContainerMemory(&pod, redisPort)
 			c.Pods[ip] = p
 			//fmt.Printf("> Pod added (missing): %s\n", p.Name)
 		}
 	}
 	//fmt.Println("Update done")
 }
+
+// redisContainerMemory returns the memory request and limit of the container
+// exposing the Redis port, or of the first container when no port is declared
+func redisContainerMemory(pod *v1.Pod, redisPort int32) (int64, int64) {
+	if len(pod.Spec.Containers) == 0 {
+		return 0, 0
+	}
+	container := pod.Spec.Containers[0]
+	for _, cont := range pod.Spec.Containers {
+		for _, port := range cont.Ports {
+			if port.ContainerPort == redisPort {
+				container = cont
+			}
+		}
+	}
+	return container.Resources.Requests.Memory().Value(), container.Resources.Limits.Memory().Value()
+}
+
+// UpdateNodes sets the zone and region of the pods, given the K8s Node labels of their hosts
+func (c *ClusterInfo) UpdateNodes(nodeList *v1.NodeList) {
+	for _, node := range nodeList.Items {
+		zone := nodeLabel(node.Labels, ZoneLabel, ZoneLabelBeta)
+		region := nodeLabel(node.Labels, RegionLabel, RegionLabelBeta)
+		for ip, p := range c.Pods {
+			if p.Host == node.Name {
+				p.Zone = zone
+				p.Region = region
+				c.Pods[ip] = p
+			}
+		}
+	}
+}
+
+// Zones returns the known zones of the K8s hosts running the pods
+func (c *ClusterInfo) Zones() []string {
+	found := map[string]bool{}
+	zones := []string{}
+	for _, p := range c.Pods {
+		if p.Zone != "" && !found[p.Zone] {
+			found[p.Zone] = true
+			zones = append(zones, p.Zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// nodeLabel returns the value of the first existing label
+func nodeLabel(labels map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package k8s
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Label set on pods created by a Deployment, also used as suffix of the ReplicaSet name
+const podTemplateHashLabel = "pod-template-hash"
+
+// PodRef identifies a pod and the workload owning it, like deployment/app
+type PodRef struct {
+	Namespace string
+	Name      string
+	Owner     string
+}
+
+// PodRefs maps pod IPs to pods, used to find the pods behind client addresses
+type PodRefs map[string]PodRef
+
+// UpdatePods adds the running pods with an own IP, pods on the host network are skipped
+// since they share the IP of the K8s node
+func (r PodRefs) UpdatePods(podList *v1.PodList) {
+	for _, pod := range podList.Items {
+		if pod.Status.PodIP == "" || pod.Spec.HostNetwork ||
+			pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		r[pod.Status.PodIP] = PodRef{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Owner:     podOwner(&pod),
+		}
+	}
+}
+
+// podOwner returns the kind and name of the controller owning the pod. Pods created by
+// a Deployment are owned by the Deployment, given by the ReplicaSet name without hash.
+func podOwner(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if hash, ok := pod.Labels[podTemplateHashLabel]; ok && ref.Kind == "ReplicaSet" {
+			if name := strings.TrimSuffix(ref.Name, "-"+hash); name != ref.Name {
+				return "deployment/" + name
+			}
+		}
+		return strings.ToLower(ref.Kind) + "/" + ref.Name
+	}
+	return ""
+}
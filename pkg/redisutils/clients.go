@@ -0,0 +1,83 @@
+package redisutils
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// ClientInfo is a connection from CLIENT LIST
+type ClientInfo struct {
+	ID    int64
+	Addr  string
+	Name  string
+	Age   int64
+	Idle  int64
+	Flags string
+	Cmd   string
+
+	// Output buffer length in bytes, list length and memory usage
+	OutputBufferLength int64
+	OutputListLength   int64
+	OutputMemory       int64
+}
+
+// IP returns the IP address of the client, without port
+func (c *ClientInfo) IP() string {
+	ip := c.Addr
+	if i := strings.LastIndex(ip, ":"); i >= 0 {
+		ip = ip[:i]
+	}
+	return strings.Trim(ip, "[]")
+}
+
+// ClientList fetches all connections, except the connection used to query them
+func (c *Connection) ClientList() ([]ClientInfo, error) {
+	var ctx = context.Background()
+
+	reply, err := c.Client.ClientList(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+	// Available from Redis 5.0
+	selfID, err := c.Client.ClientID(ctx).Result()
+	if err != nil {
+		selfID = -1
+	}
+
+	clients := []ClientInfo{}
+	for _, line := range strings.Split(reply, "\n") {
+		fields := map[string]string{}
+		for _, field := range strings.Fields(line) {
+			keyVal := strings.SplitN(field, "=", 2)
+			if len(keyVal) == 2 {
+				fields[keyVal[0]] = keyVal[1]
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		client := ClientInfo{
+			ID:                 parseInt(fields["id"]),
+			Addr:               fields["addr"],
+			Name:               fields["name"],
+			Age:                parseInt(fields["age"]),
+			Idle:               parseInt(fields["idle"]),
+			Flags:              fields["flags"],
+			Cmd:                fields["cmd"],
+			OutputBufferLength: parseInt(fields["obl"]),
+			OutputListLength:   parseInt(fields["oll"]),
+			OutputMemory:       parseInt(fields["omem"]),
+		}
+		if client.ID == selfID {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+func parseInt(value string) int64 {
+	i, _ := strconv.ParseInt(value, 10, 64)
+	return i
+}
@@ -1,13 +1,16 @@
 package redisutils
 
 import (
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // Structure of CLUSTER NODES result:
 // id, ip:port@port, flags(self/master..), master-id, ping, pong, config-epoch, linkstate, slot
 
-// ClusterNodes is a type that holds the result from one query
+// ClusterNodes is a type that holds the result from one query, keyed by node ID.
+// Multiple nodes can share an address, like a node in handshake or a stale node.
 type ClusterNodes map[string][]string
 
 const MinElements = 6
@@ -20,8 +23,8 @@ func NewClusterNodes(cliData string) ClusterNodes {
 		if len(keyVals) > MinElements {
 			addr := strings.Split(keyVals[1], ":")
 			if len(addr) > 1 {
-				ip := addr[0]
-				nodes[ip] = keyVals
+				id := keyVals[0]
+				nodes[id] = keyVals
 			}
 		}
 	}
@@ -38,3 +41,192 @@ func (n *ClusterNodes) GetFlagsSelf() string {
 	}
 	return ""
 }
+
+// States of an open slot
+const (
+	SlotMigrating = "migrating"
+	SlotImporting = "importing"
+)
+
+// OpenSlot is a slot in migrating or importing state, as shown by the node itself
+type OpenSlot struct {
+	Slot  int
+	State string
+	// The node that the slot is migrating to, or importing from
+	NodeID string
+}
+
+// ClusterNode is a parsed line from CLUSTER NODES
+type ClusterNode struct {
+	ID          string
+	Addr        string
+	Flags       []string
+	MasterID    string
+	PingSent    int64
+	PongRecv    int64
+	ConfigEpoch int64
+	LinkState   string
+	Slots       []string
+}
+
+// ParseClusterNode converts the fields of a CLUSTER NODES line
+func ParseClusterNode(fields []string) ClusterNode {
+	node := ClusterNode{}
+	if len(fields) <= MinElements {
+		return node
+	}
+	node.ID = fields[0]
+	node.Addr = fields[1]
+	node.Flags = strings.Split(fields[2], ",")
+	if fields[3] != "-" {
+		node.MasterID = fields[3]
+	}
+	node.PingSent, _ = strconv.ParseInt(fields[4], 10, 64)
+	node.PongRecv, _ = strconv.ParseInt(fields[5], 10, 64)
+	node.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+	if len(fields) > 7 {
+		node.LinkState = fields[7]
+	}
+	if len(fields) > 8 {
+		node.Slots = fields[8:]
+	}
+	return node
+}
+
+// HasFlag checks if the node has a specific flag set
+func (n *ClusterNode) HasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns all parsed nodes, sorted by address
+func (n *ClusterNodes) List() []ClusterNode {
+	list := []ClusterNode{}
+	for _, fields := range *n {
+		list = append(list, ParseClusterNode(fields))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Addr < list[j].Addr
+	})
+	return list
+}
+
+// GetSelf returns the parsed node that represents the queried instance
+func (n *ClusterNodes) GetSelf() (ClusterNode, bool) {
+	for _, fields := range *n {
+		if strings.Contains(fields[2], "myself") {
+			return ParseClusterNode(fields), true
+		}
+	}
+	return ClusterNode{}, false
+}
+
+// Role returns master or replica, or an empty string when unknown
+func (n *ClusterNode) Role() string {
+	if n.HasFlag("master") {
+		return "master"
+	}
+	if n.HasFlag("slave") {
+		return "replica"
+	}
+	return ""
+}
+
+// GetNode returns the parsed node with given node ID
+func (n *ClusterNodes) GetNode(id string) (ClusterNode, bool) {
+	for _, fields := range *n {
+		if fields[0] == id {
+			return ParseClusterNode(fields), true
+		}
+	}
+	return ClusterNode{}, false
+}
+
+// GetNodeByIP returns the parsed node with given IP. When multiple nodes uses
+// the IP, like a stale node or a node in handshake, a healthy node is preferred.
+func (n *ClusterNodes) GetNodeByIP(ip string) (ClusterNode, bool) {
+	rank := func(node ClusterNode) int {
+		switch {
+		case node.HasFlag("handshake"):
+			return 2
+		case node.HasFlag("fail") || node.HasFlag("noaddr"):
+			return 1
+		}
+		return 0
+	}
+
+	result := ClusterNode{}
+	found := false
+	for _, fields := range *n {
+		if strings.Split(fields[1], ":")[0] != ip {
+			continue
+		}
+		node := ParseClusterNode(fields)
+		if !found || rank(node) < rank(result) ||
+			(rank(node) == rank(result) && node.ID < result.ID) {
+			result = node
+			found = true
+		}
+	}
+	return result, found
+}
+
+// SlotRanges returns the slot ranges served by the node, excluding
+// slots in migrating or importing state
+func (n *ClusterNode) SlotRanges() [][2]int {
+	ranges := [][2]int{}
+	for _, s := range n.Slots {
+		if strings.HasPrefix(s, "[") {
+			continue
+		}
+		parts := strings.SplitN(s, "-", 2)
+		start, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		end := start
+		if len(parts) > 1 {
+			if end, err = strconv.Atoi(parts[1]); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// OpenSlots returns the slots in migrating or importing state. Open slots are
+// only shown for the queried node itself, as [slot->-nodeid] or [slot-<-nodeid].
+func (n *ClusterNode) OpenSlots() []OpenSlot {
+	result := []OpenSlot{}
+	for _, s := range n.Slots {
+		if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+			continue
+		}
+		s = s[1 : len(s)-1]
+
+		open := OpenSlot{}
+		parts := strings.SplitN(s, "->-", 2)
+		open.State = SlotMigrating
+		if len(parts) != 2 {
+			parts = strings.SplitN(s, "-<-", 2)
+			open.State = SlotImporting
+		}
+		if len(parts) != 2 {
+			continue
+		}
+
+		slot, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		open.Slot = slot
+		open.NodeID = parts[1]
+		result = append(result, open)
+	}
+	return result
+}
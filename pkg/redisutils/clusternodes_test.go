@@ -0,0 +1,144 @@
+package redisutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseClusterNode(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want ClusterNode
+	}{
+		{
+			name: "master with slots",
+			line: "07c37dfeb235213a872192d90877d0cd55635b91 10.244.1.5:6379@16379 myself,master - 0 1602151311000 1 connected 0-5460",
+			want: ClusterNode{
+				ID:          "07c37dfeb235213a872192d90877d0cd55635b91",
+				Addr:        "10.244.1.5:6379@16379",
+				Flags:       []string{"myself", "master"},
+				PongRecv:    1602151311000,
+				ConfigEpoch: 1,
+				LinkState:   "connected",
+				Slots:       []string{"0-5460"},
+			},
+		},
+		{
+			name: "replica",
+			line: "6ec23923021cf3ffec47632106199cb7f496ce01 10.244.2.6:6379@16379 slave " +
+				"07c37dfeb235213a872192d90877d0cd55635b91 1602151312000 1602151312010 1 disconnected",
+			want: ClusterNode{
+				ID:          "6ec23923021cf3ffec47632106199cb7f496ce01",
+				Addr:        "10.244.2.6:6379@16379",
+				Flags:       []string{"slave"},
+				MasterID:    "07c37dfeb235213a872192d90877d0cd55635b91",
+				PingSent:    1602151312000,
+				PongRecv:    1602151312010,
+				ConfigEpoch: 1,
+				LinkState:   "disconnected",
+			},
+		},
+		{
+			name: "master with open slots",
+			line: "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 10.244.2.5:6379@16379 myself,master - 0 0 2 connected " +
+				"5461-10922 12000 [5461->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f] [12001-<-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]",
+			want: ClusterNode{
+				ID:          "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1",
+				Addr:        "10.244.2.5:6379@16379",
+				Flags:       []string{"myself", "master"},
+				ConfigEpoch: 2,
+				LinkState:   "connected",
+				Slots: []string{"5461-10922", "12000",
+					"[5461->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]",
+					"[12001-<-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]"},
+			},
+		},
+		{
+			name: "too few fields",
+			line: "07c37dfeb235213a872192d90877d0cd55635b91 10.244.1.5:6379@16379 master - 0 0",
+			want: ClusterNode{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseClusterNode(strings.Split(tt.line, " "))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterNodeSlots(t *testing.T) {
+	tests := []struct {
+		name      string
+		slots     []string
+		wantRange [][2]int
+		wantOpen  []OpenSlot
+	}{
+		{
+			name:      "no slots",
+			wantRange: [][2]int{},
+			wantOpen:  []OpenSlot{},
+		},
+		{
+			name:      "ranges and single slots",
+			slots:     []string{"0-5460", "5462", "16383"},
+			wantRange: [][2]int{{0, 5460}, {5462, 5462}, {16383, 16383}},
+			wantOpen:  []OpenSlot{},
+		},
+		{
+			name: "migrating and importing",
+			slots: []string{"5461-10922",
+				"[5461->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]",
+				"[12001-<-07c37dfeb235213a872192d90877d0cd55635b91]"},
+			wantRange: [][2]int{{5461, 10922}},
+			wantOpen: []OpenSlot{
+				{Slot: 5461, State: SlotMigrating, NodeID: "292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f"},
+				{Slot: 12001, State: SlotImporting, NodeID: "07c37dfeb235213a872192d90877d0cd55635b91"},
+			},
+		},
+		{
+			name:      "malformed entries ignored",
+			slots:     []string{"a-b", "1-x", "[5461]", "[x->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]", "[12001-<-"},
+			wantRange: [][2]int{},
+			wantOpen:  []OpenSlot{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := ClusterNode{Slots: tt.slots}
+			if got := node.SlotRanges(); !reflect.DeepEqual(got, tt.wantRange) {
+				t.Errorf("SlotRanges() = %v, want %v", got, tt.wantRange)
+			}
+			if got := node.OpenSlots(); !reflect.DeepEqual(got, tt.wantOpen) {
+				t.Errorf("OpenSlots() = %+v, want %+v", got, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestGetNodeByIP(t *testing.T) {
+	nodes := NewClusterNodes(
+		"07c37dfeb235213a872192d90877d0cd55635b91 10.244.1.5:6379@16379 master,fail - 0 1602151311000 1 disconnected\n" +
+			"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 10.244.2.5:6379@16379 myself,master - 0 0 2 connected 0-16383\n" +
+			"e2b6b5c2f1d2b1bbd8fa6d49a5b1ee9d1bd1e6a2 10.244.1.5:6379@16379 handshake - 0 0 0 connected\n" +
+			"a4f1ba0a2b3e41c56e1bb3bc0af2dbe37bca63e4 10.244.1.5:6379@16379 master - 0 1602151411000 3 connected\n")
+
+	tests := []struct {
+		ip     string
+		wantID string
+	}{
+		{ip: "10.244.1.5", wantID: "a4f1ba0a2b3e41c56e1bb3bc0af2dbe37bca63e4"},
+		{ip: "10.244.2.5", wantID: "67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1"},
+		{ip: "10.244.3.5", wantID: ""},
+	}
+	for _, tt := range tests {
+		node, found := nodes.GetNodeByIP(tt.ip)
+		if node.ID != tt.wantID || found != (tt.wantID != "") {
+			t.Errorf("GetNodeByIP(%q) = %q, %v, want %q", tt.ip, node.ID, found, tt.wantID)
+		}
+	}
+}
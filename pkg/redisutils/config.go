@@ -0,0 +1,21 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConfigGetAll fetches all configuration parameters
+func (c *Connection) ConfigGetAll() (map[string]string, error) {
+	var ctx = context.Background()
+
+	reply, err := c.Client.ConfigGet(ctx, "*").Result()
+	if err != nil {
+		return nil, err
+	}
+	config := make(map[string]string)
+	for i := 0; i+1 < len(reply); i += 2 {
+		config[fmt.Sprint(reply[i])] = fmt.Sprint(reply[i+1])
+	}
+	return config, nil
+}
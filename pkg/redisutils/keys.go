@@ -0,0 +1,90 @@
+package redisutils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// KeySize is the type and the memory usage in bytes of a key
+type KeySize struct {
+	Key   string
+	Type  string
+	Bytes int64
+}
+
+// ScanKeySizes iterates over all keys using SCAN with the given count hint, and passes the
+// type and memory usage of each batch of keys to the given function. It sleeps between the
+// batches to limit the load on the Redis instance.
+func (c *Connection) ScanKeySizes(count int64, sleep time.Duration, fn func([]KeySize)) error {
+	rdb := c.Client
+	var ctx = context.Background()
+
+	var cursor uint64
+	for {
+		keys, next, err := rdb.Scan(ctx, cursor, "", count).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			types := make([]*redis.StatusCmd, len(keys))
+			usages := make([]*redis.IntCmd, len(keys))
+			pipe := rdb.Pipeline()
+			for i, key := range keys {
+				types[i] = pipe.Type(ctx, key)
+				usages[i] = pipe.MemoryUsage(ctx, key)
+			}
+			// Errors are checked per key, since keys removed after the SCAN gives nil replies
+			_, _ = pipe.Exec(ctx)
+
+			sizes := []KeySize{}
+			for i, key := range keys {
+				for _, err := range []error{types[i].Err(), usages[i].Err()} {
+					if err != nil && err != redis.Nil {
+						return err
+					}
+				}
+				if types[i].Val() == "none" || usages[i].Err() == redis.Nil {
+					continue
+				}
+				sizes = append(sizes, KeySize{Key: key, Type: types[i].Val(), Bytes: usages[i].Val()})
+			}
+			fn(sizes)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+		time.Sleep(sleep)
+	}
+}
+
+// KeySlot returns the hash slot of a key, using the hash tag when the key has one
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % SlotCount)
+}
+
+// crc16 implements the CRC16-CCITT (XModem) checksum used by Redis Cluster
+func crc16(data string) uint16 {
+	var crc uint16
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
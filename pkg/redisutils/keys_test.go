@@ -0,0 +1,46 @@
+package redisutils
+
+import "testing"
+
+func TestCrc16(t *testing.T) {
+	tests := []struct {
+		data string
+		want uint16
+	}{
+		{data: "", want: 0},
+		{data: "123456789", want: 0x31c3},
+	}
+	for _, tt := range tests {
+		if got := crc16(tt.data); got != tt.want {
+			t.Errorf("crc16(%q) = %#x, want %#x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key     string
+		hashKey string
+		want    int
+	}{
+		{key: "foo", want: 12182},
+		{key: "123456789", want: 12739},
+		{key: "{foo}", want: 12182},
+		{key: "{user1000}.following", hashKey: "user1000"},
+		{key: "{user1000}.followers", hashKey: "user1000"},
+		{key: "foo{bar}{zap}", hashKey: "bar"},
+		{key: "foo{{bar}}zap", hashKey: "{bar"},
+		// An empty hash tag is not used, the whole key is hashed
+		{key: "foo{}{bar}", hashKey: "foo{}{bar}"},
+		{key: "foo{bar", hashKey: "foo{bar"},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		if tt.hashKey != "" {
+			want = int(crc16(tt.hashKey) % SlotCount)
+		}
+		if got := KeySlot(tt.key); got != want {
+			t.Errorf("KeySlot(%q) = %d, want %d", tt.key, got, want)
+		}
+	}
+}
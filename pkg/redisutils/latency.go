@@ -0,0 +1,139 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LatencyEvent is an event from LATENCY LATEST
+type LatencyEvent struct {
+	Event  string
+	Time   time.Time
+	Latest time.Duration
+	Max    time.Duration
+}
+
+// LatencyHistogram is the latency distribution of a command from LATENCY HISTOGRAM.
+// The buckets are given by their upper bound and have cumulative counts.
+type LatencyHistogram struct {
+	Command string
+	Calls   int64
+	Buckets []LatencyBucket
+}
+
+// LatencyBucket is a bucket of a latency histogram
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// Percentile returns the upper bound of the bucket holding the given percentile of the calls
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	for _, b := range h.Buckets {
+		if float64(b.Count) >= p/100*float64(h.Calls) {
+			return b.UpperBound
+		}
+	}
+	if len(h.Buckets) == 0 {
+		return 0
+	}
+	return h.Buckets[len(h.Buckets)-1].UpperBound
+}
+
+// LatencyLatest fetches the latest latency events, recorded when latency-monitor-threshold is set
+func (c *Connection) LatencyLatest() ([]LatencyEvent, error) {
+	var ctx = context.Background()
+
+	reply, err := c.Client.Do(ctx, "latency", "latest").Result()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected LATENCY LATEST reply: %v", reply)
+	}
+
+	events := []LatencyEvent{}
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, fmt.Errorf("unexpected LATENCY LATEST entry: %v", item)
+		}
+		event, _ := fields[0].(string)
+		timestamp, _ := fields[1].(int64)
+		latest, _ := fields[2].(int64)
+		max, _ := fields[3].(int64)
+		events = append(events, LatencyEvent{
+			Event:  event,
+			Time:   time.Unix(timestamp, 0),
+			Latest: time.Duration(latest) * time.Millisecond,
+			Max:    time.Duration(max) * time.Millisecond,
+		})
+	}
+	return events, nil
+}
+
+// LatencyHistograms fetches the latency histogram of all called commands.
+// Returns nil without error when not supported, like before Redis 7.0.
+func (c *Connection) LatencyHistograms() ([]LatencyHistogram, error) {
+	var ctx = context.Background()
+
+	reply, err := c.Client.Do(ctx, "latency", "histogram").Result()
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "unknown subcommand") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items)%2 != 0 {
+		return nil, fmt.Errorf("unexpected LATENCY HISTOGRAM reply: %v", reply)
+	}
+
+	histograms := []LatencyHistogram{}
+	for i := 0; i < len(items); i += 2 {
+		command, _ := items[i].(string)
+		fields, _ := items[i+1].([]interface{})
+		h := LatencyHistogram{Command: command}
+		for j := 0; j+1 < len(fields); j += 2 {
+			switch name, _ := fields[j].(string); name {
+			case "calls":
+				h.Calls, _ = fields[j+1].(int64)
+			case "histogram_usec":
+				buckets, _ := fields[j+1].([]interface{})
+				for k := 0; k+1 < len(buckets); k += 2 {
+					bound, _ := buckets[k].(int64)
+					count, _ := buckets[k+1].(int64)
+					h.Buckets = append(h.Buckets, LatencyBucket{
+						UpperBound: time.Duration(bound) * time.Microsecond,
+						Count:      count,
+					})
+				}
+			}
+		}
+		histograms = append(histograms, h)
+	}
+	return histograms, nil
+}
+
+// PingSamples measures the round-trip time of the given number of PINGs, with a pause between them.
+// A first PING sets up the connection and is not measured.
+func (c *Connection) PingSamples(samples int, pause time.Duration) ([]time.Duration, error) {
+	var ctx = context.Background()
+
+	if err := c.Client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+	result := []time.Duration{}
+	for i := 0; i < samples; i++ {
+		time.Sleep(pause)
+		start := time.Now()
+		if err := c.Client.Ping(ctx).Err(); err != nil {
+			return result, err
+		}
+		result = append(result, time.Since(start))
+	}
+	return result, nil
+}
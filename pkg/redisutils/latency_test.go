@@ -0,0 +1,40 @@
+package redisutils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogramPercentile(t *testing.T) {
+	// Captured LATENCY HISTOGRAM SET reply, with cumulative counts
+	set := LatencyHistogram{
+		Command: "set",
+		Calls:   100000,
+		Buckets: []LatencyBucket{
+			{UpperBound: 1 * time.Microsecond, Count: 99904},
+			{UpperBound: 2 * time.Microsecond, Count: 99968},
+			{UpperBound: 4 * time.Microsecond, Count: 99992},
+			{UpperBound: 8 * time.Microsecond, Count: 99999},
+			{UpperBound: 16 * time.Microsecond, Count: 100000},
+		},
+	}
+
+	tests := []struct {
+		histogram LatencyHistogram
+		p         float64
+		want      time.Duration
+	}{
+		{histogram: set, p: 0, want: 1 * time.Microsecond},
+		{histogram: set, p: 50, want: 1 * time.Microsecond},
+		{histogram: set, p: 99.9, want: 1 * time.Microsecond},
+		{histogram: set, p: 99.95, want: 2 * time.Microsecond},
+		{histogram: set, p: 99.99, want: 4 * time.Microsecond},
+		{histogram: set, p: 100, want: 16 * time.Microsecond},
+		{histogram: LatencyHistogram{Command: "get"}, p: 99, want: 0},
+	}
+	for _, tt := range tests {
+		if got := tt.histogram.Percentile(tt.p); got != tt.want {
+			t.Errorf("%s p%v = %v, want %v", tt.histogram.Command, tt.p, got, tt.want)
+		}
+	}
+}
@@ -15,6 +15,9 @@ import (
 const RedisPort = 6379
 const Timeout = 2
 
+// SlotCount is the number of hash slots in a Redis Cluster
+const SlotCount = 16384
+
 type RedisInfo map[string]string
 type ClusterSlots []redis.ClusterSlot
 
@@ -25,25 +28,34 @@ func (s BySlot) Len() int           { return len(s) }
 func (s BySlot) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s BySlot) Less(i, j int) bool { return s[i].Start < s[j].Start }
 
-func QueryRedis(pfwd *portforwarder.PortForwarder, namespace string, podName string, podPort int) (RedisInfo, ClusterNodes, ClusterSlots, error) {
+// Connection is a connection to a Redis instance in a pod, via a portforward
+type Connection struct {
+	Client *redis.Client
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Connect sets up a portforward to a pod and connects to its Redis instance
+func Connect(pfwd *portforwarder.PortForwarder, namespace string, podName string, podPort int) (*Connection, error) {
 	localPort, err := portforwarder.GetAvailableLocalPort()
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
-	stopCh := make(chan struct{}, 1)
+	conn := &Connection{
+		stopCh: make(chan struct{}, 1),
+	}
 	readyCh := make(chan struct{})
-	errorCh := make(chan error)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
+	errorCh := make(chan error, 1)
 
+	conn.wg.Add(1)
 	go func() {
-		err = pfwd.ForwardPort(namespace, podName, localPort, podPort, stopCh, readyCh)
+		err := pfwd.ForwardPort(namespace, podName, localPort, podPort, conn.stopCh, readyCh)
 		if err != nil {
 			errorCh <- err
 		}
-		wg.Done()
+		conn.wg.Done()
 	}()
 
 	// Wait for portforwaring to be ready
@@ -51,20 +63,35 @@ func QueryRedis(pfwd *portforwarder.PortForwarder, namespace string, podName str
 	case <-readyCh:
 		break
 	case err := <-errorCh:
-		close(stopCh)
-		return nil, nil, nil, err
+		close(conn.stopCh)
+		return nil, err
 	case <-time.After(Timeout * time.Second):
-		close(stopCh)
-		return nil, nil, nil, fmt.Errorf("could not setup a portforward to %s/%s:%d", namespace, podName, podPort)
+		close(conn.stopCh)
+		return nil, fmt.Errorf("could not setup a portforward to %s/%s:%d", namespace, podName, podPort)
 	}
 
 	// Connect to Redis instance in pod (using portforwarding)
-	rdb := redis.NewClient(&redis.Options{
+	conn.Client = redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf("localhost:%d", localPort),
 	})
+	return conn, nil
+}
+
+// Close the Redis connection and the portforward
+func (c *Connection) Close() {
+	c.Client.Close()
+	close(c.stopCh)
+
+	// Wait for portforwarder goroutine to exit
+	c.wg.Wait()
+}
+
+// Query fetches the information about the Redis instance and its cluster view
+func (c *Connection) Query() (RedisInfo, ClusterNodes, ClusterSlots, error) {
+	rdb := c.Client
 	var ctx = context.Background()
 
-	_, err = rdb.Ping(ctx).Result()
+	_, err := rdb.Ping(ctx).Result()
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -94,8 +121,6 @@ func QueryRedis(pfwd *portforwarder.PortForwarder, namespace string, podName str
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	// Done with the portforwarder
-	close(stopCh)
 
 	// Parse query responses
 	info := make(map[string]string)
@@ -118,8 +143,17 @@ func QueryRedis(pfwd *portforwarder.PortForwarder, namespace string, podName str
 	// Parse cluster nodes data
 	nodes := NewClusterNodes(cNodes)
 
-	// Wait for portforwarder goroutine to exit
-	wg.Wait()
-
 	return info, nodes, slots, nil
 }
+
+// QueryRedis fetches the information about a Redis instance using a temporary portforward
+func QueryRedis(pfwd *portforwarder.PortForwarder, namespace string, podName string, podPort int) (RedisInfo, ClusterNodes, ClusterSlots, error) {
+	conn, err := Connect(pfwd, namespace, podName, podPort)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Done with the portforwarder when returning
+	defer conn.Close()
+
+	return conn.Query()
+}
@@ -0,0 +1,84 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SlowLogEntry is an entry from SLOWLOG GET
+type SlowLogEntry struct {
+	ID       int64
+	Time     time.Time
+	Duration time.Duration
+	Args     []string
+
+	// Available from Redis 4.0
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLogGet fetches the given number of latest slow log entries
+func (c *Connection) SlowLogGet(count int64) ([]SlowLogEntry, error) {
+	var ctx = context.Background()
+
+	reply, err := c.Client.Do(ctx, "slowlog", "get", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseSlowLog(reply)
+}
+
+// SlowLogGetAndReset fetches all slow log entries and clears the slow log in a transaction,
+// so that no entry is lost between fetching and clearing
+func (c *Connection) SlowLogGetAndReset() ([]SlowLogEntry, error) {
+	var ctx = context.Background()
+
+	var get *redis.Cmd
+	_, err := c.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Do(ctx, "slowlog", "get", -1) // A negative count gives all entries
+		pipe.Do(ctx, "slowlog", "reset")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseSlowLog(get.Val())
+}
+
+// parseSlowLog parses a SLOWLOG GET reply
+func parseSlowLog(reply interface{}) ([]SlowLogEntry, error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected SLOWLOG GET reply: %v", reply)
+	}
+
+	entries := []SlowLogEntry{}
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, fmt.Errorf("unexpected SLOWLOG GET entry: %v", item)
+		}
+		id, _ := fields[0].(int64)
+		timestamp, _ := fields[1].(int64)
+		micros, _ := fields[2].(int64)
+		entry := SlowLogEntry{
+			ID:       id,
+			Time:     time.Unix(timestamp, 0),
+			Duration: time.Duration(micros) * time.Microsecond,
+		}
+		if args, ok := fields[3].([]interface{}); ok {
+			for _, arg := range args {
+				entry.Args = append(entry.Args, fmt.Sprint(arg))
+			}
+		}
+		if len(fields) >= 6 {
+			entry.ClientAddr, _ = fields[4].(string)
+			entry.ClientName, _ = fields[5].(string)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}